@@ -0,0 +1,133 @@
+package dbwrap
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrMaxRows ошибка.
+var ErrMaxRows = errors.New("превышено максимальное количество строк результата")
+
+type (
+	timeoutKey   struct{}
+	queryNameKey struct{}
+	maxRowsKey   struct{}
+)
+
+// QueryTimeout контекст с таймаутом запросов вместо SetTimeoutQuery,
+// timeout <= 0 - без ограничения времени выполнения (NoQueryTimeout).
+//
+// rows, err := db.SelectMapsContext(dbwrap.QueryTimeout(ctx, 30*time.Minute), reportQuery)
+func QueryTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, timeoutKey{}, timeout)
+}
+
+// NoQueryTimeout контекст без ограничения времени выполнения запросов,
+// остаётся действующим только срок самого ctx.
+func NoQueryTimeout(ctx context.Context) context.Context {
+	return QueryTimeout(ctx, 0)
+}
+
+// QueryName контекст с именем запроса для логов, метрик и текста ошибок.
+func QueryName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, queryNameKey{}, name)
+}
+
+// QueryNameFrom имя запроса из контекста, заданное QueryName.
+func QueryNameFrom(ctx context.Context) string {
+	name, _ := ctx.Value(queryNameKey{}).(string)
+	return name
+}
+
+// MaxRows контекст с ограничением количества строк, читаемых в память SelectContext
+// и SelectMapsContext или выгружаемых ExportContext, при превышении возвращается ErrMaxRows. n <= 0 - без ограничения.
+func MaxRows(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, maxRowsKey{}, n)
+}
+
+func maxRowsFrom(ctx context.Context) int {
+	n, _ := ctx.Value(maxRowsKey{}).(int)
+	return n
+}
+
+// queryContext контекст запроса с таймаутом из QueryTimeout или SetTimeoutQuery.
+func (d *DBSQL) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout, ok := ctx.Value(timeoutKey{}).(time.Duration)
+	if !ok {
+		timeout = time.Duration(d.timeoutQuery) * time.Second
+	} else if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// done запись выполненного запроса в лог (WithLogger, уровень Debug)
+// и ошибка с текстом запроса и именем из QueryName.
+func (d *DBSQL) done(ctx context.Context, start time.Time, err error, query string, args ...any) error {
+	name := QueryNameFrom(ctx)
+	if d.logger != nil {
+		d.logger.DebugContext(ctx, "query", "name", name, "query", query, "duration", time.Since(start), "error", err)
+	}
+	if err == nil {
+		return nil
+	}
+	if name != "" {
+		return fmt.Errorf("%s: %w", name, sqlErr(err, query, args...))
+	}
+	return sqlErr(err, query, args...)
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// selectMax чтение строк запроса в слайс dest не более maxRows строк.
+// Повторяет sqlx.SelectContext: элементы-структуры заполняются StructScan, остальные Scan,
+// прежнее содержимое dest заменяется.
+func selectMax(ctx context.Context, q sqlx.QueryerContext, dest any, maxRows int, query string, args ...any) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("select dest %T: ожидается указатель на слайс", dest)
+	}
+	slice := v.Elem()
+	elem := slice.Type().Elem()
+	isPtr := elem.Kind() == reflect.Ptr
+	base := elem
+	if isPtr {
+		base = elem.Elem()
+	}
+	scannable := base.Kind() != reflect.Struct || reflect.PointerTo(base).Implements(scannerType) ||
+		base == reflect.TypeOf(time.Time{})
+
+	rows, err := q.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	slice.SetLen(0)
+	for rows.Next() {
+		if slice.Len() >= maxRows {
+			return fmt.Errorf("%w: %d", ErrMaxRows, maxRows)
+		}
+		vp := reflect.New(base)
+		if scannable {
+			err = rows.Scan(vp.Interface())
+		} else {
+			err = rows.StructScan(vp.Interface())
+		}
+		if err != nil {
+			return err
+		}
+		if isPtr {
+			slice.Set(reflect.Append(slice, vp))
+		} else {
+			slice.Set(reflect.Append(slice, vp.Elem()))
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mpuzanov/dbwrap"
+)
+
+// genOptions параметры генерации.
+type genOptions struct {
+	Package string   // имя пакета
+	Tables  []string // таблицы (имя или схема.имя), пустой - все таблицы и представления
+	CRUD    bool     // функции Get, Insert, Update, Delete для таблиц с первичным ключом
+}
+
+// commonInitialisms сокращения, которые пишутся в верхнем регистре (как в golint).
+var commonInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true,
+	"GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "INN": true, "IP": true,
+	"JSON": true, "KPP": true, "LHS": true, "OGRN": true, "QPS": true, "RAM": true, "RHS": true,
+	"RPC": true, "SLA": true, "SMTP": true, "SQL": true, "SSH": true, "TCP": true, "TLS": true,
+	"TTL": true, "UDP": true, "UI": true, "UID": true, "UUID": true, "URI": true, "URL": true,
+	"UTF8": true, "VM": true, "XML": true, "XMPP": true, "XSRF": true, "XSS": true,
+}
+
+// goName имя Go из имени БД: last_name -> LastName, customer_id -> CustomerID.
+func goName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var sb strings.Builder
+	for _, w := range words {
+		if upper := strings.ToUpper(w); commonInitialisms[upper] {
+			sb.WriteString(upper)
+			continue
+		}
+		r := []rune(w)
+		// CamelCase из БД (LastName, orderItems) сохраняется
+		allUpper := strings.ToUpper(w) == w
+		for i := range r {
+			switch {
+			case i == 0:
+				r[i] = unicode.ToUpper(r[i])
+			case allUpper:
+				r[i] = unicode.ToLower(r[i])
+			}
+		}
+		sb.WriteString(string(r))
+	}
+	s := sb.String()
+	if s == "" {
+		return "X"
+	}
+	if first := []rune(s)[0]; !unicode.IsLetter(first) {
+		s = "X" + s
+	}
+	return s
+}
+
+// goType тип поля Go для колонки, для колонок с NULL - указатель.
+// imports дополняется пакетами, которые нужны для типа.
+func goType(driver string, c *dbwrap.Column, imports map[string]bool) string {
+	typ := baseGoType(driver, c)
+	switch typ {
+	case "time.Time":
+		imports["time"] = true
+	case "mssql.UniqueIdentifier":
+		imports["github.com/microsoft/go-mssqldb"] = true
+	}
+	if c.Nullable && typ != "[]byte" && typ != "any" {
+		return "*" + typ
+	}
+	return typ
+}
+
+func baseGoType(driver string, c *dbwrap.Column) string {
+	dataType := c.DataType
+	if fields := strings.Fields(dataType); len(fields) > 0 && fields[0] != "double" &&
+		fields[0] != "timestamp" && fields[0] != "time" {
+		dataType = fields[0] // int unsigned, character varying
+	}
+	switch dataType {
+	case "bool", "boolean":
+		return "bool"
+	case "bit":
+		if c.Length <= 1 {
+			return "bool"
+		}
+		return "[]byte"
+	case "tinyint":
+		if driver == "mysql" && c.Length == 1 {
+			return "bool"
+		}
+		return "int"
+	case "bigint", "int8", "bigserial":
+		return "int64"
+	case "int", "integer", "smallint", "mediumint", "int2", "int4", "serial", "smallserial", "year":
+		return "int"
+	case "float", "float4", "float8", "real", "double", "double precision", "numeric", "decimal",
+		"money", "smallmoney", "number":
+		return "float64"
+	case "date", "datetime", "datetime2", "smalldatetime", "datetimeoffset", "timestamptz",
+		"timestamp with time zone", "timestamp without time zone", "time", "time with time zone",
+		"time without time zone", "timetz":
+		return "time.Time"
+	case "timestamp":
+		if driver == "sqlserver" { // rowversion
+			return "[]byte"
+		}
+		return "time.Time"
+	case "binary", "varbinary", "blob", "tinyblob", "mediumblob", "longblob", "bytea", "image", "rowversion":
+		return "[]byte"
+	case "uniqueidentifier":
+		return "mssql.UniqueIdentifier"
+	}
+	if driver == "sqlite3" {
+		return sqliteAffinityType(c.DataType)
+	}
+	return "string"
+}
+
+// sqliteAffinityType тип по правилам определения affinity sqlite3 для произвольных имён типов.
+func sqliteAffinityType(dataType string) string {
+	switch {
+	case dataType == "":
+		return "any"
+	case strings.Contains(dataType, "int"):
+		return "int64"
+	case strings.Contains(dataType, "char"), strings.Contains(dataType, "clob"), strings.Contains(dataType, "text"):
+		return "string"
+	case strings.Contains(dataType, "blob"):
+		return "[]byte"
+	case strings.Contains(dataType, "real"), strings.Contains(dataType, "floa"), strings.Contains(dataType, "doub"):
+		return "float64"
+	case strings.Contains(dataType, "date"), strings.Contains(dataType, "time"):
+		return "time.Time"
+	case strings.Contains(dataType, "bool"):
+		return "bool"
+	}
+	return "string"
+}
+
+// genTable таблица с именами Go.
+type genTable struct {
+	*dbwrap.Table
+	GoName string
+	Fields []genField
+}
+
+type genField struct {
+	*dbwrap.Column
+	GoName string
+	GoType string
+}
+
+// generate генерация файла Go со структурами таблиц схемы s.
+func generate(s *dbwrap.Schema, d dbwrap.Dialect, opts genOptions) ([]byte, error) {
+	if opts.Package == "" {
+		opts.Package = "models"
+	}
+	tables, err := selectTables(s, opts.Tables)
+	if err != nil {
+		return nil, err
+	}
+
+	imports := map[string]bool{}
+	gts := make([]*genTable, 0, len(tables))
+	structNames := map[string]int{}
+	for _, t := range tables {
+		structNames[goName(t.Name)]++
+	}
+	for _, t := range tables {
+		gt := &genTable{Table: t, GoName: goName(t.Name)}
+		// одинаковые имена таблиц в разных схемах
+		if structNames[gt.GoName] > 1 {
+			gt.GoName = goName(t.Schema) + gt.GoName
+		}
+		fieldNames := map[string]int{}
+		for _, c := range t.Columns {
+			name := goName(c.Name)
+			if fieldNames[name]++; fieldNames[name] > 1 {
+				name += strconv.Itoa(fieldNames[name])
+			}
+			gt.Fields = append(gt.Fields, genField{Column: c, GoName: name, GoType: goType(d.Name(), c, imports)})
+		}
+		if opts.CRUD && crudTable(t) {
+			imports["context"] = true
+			imports["github.com/mpuzanov/dbwrap"] = true
+		}
+		gts = append(gts, gt)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by dbwrap-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", opts.Package)
+	writeImports(&buf, imports)
+	for _, gt := range gts {
+		writeStruct(&buf, gt)
+		if opts.CRUD && crudTable(gt.Table) {
+			writeCRUD(&buf, d, gt)
+		}
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format: %w", err)
+	}
+	return src, nil
+}
+
+// selectTables таблицы схемы по списку имён, пустой список - все.
+func selectTables(s *dbwrap.Schema, names []string) ([]*dbwrap.Table, error) {
+	if len(names) == 0 {
+		return s.Tables, nil
+	}
+	tables := make([]*dbwrap.Table, 0, len(names))
+	for _, name := range names {
+		t := s.Table(name)
+		if t == nil {
+			return nil, fmt.Errorf("таблица %s не найдена", name)
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// crudTable функции CRUD создаются для таблиц с первичным ключом.
+func crudTable(t *dbwrap.Table) bool {
+	return !t.View && len(t.PrimaryKey) > 0
+}
+
+func writeImports(buf *bytes.Buffer, imports map[string]bool) {
+	if len(imports) == 0 {
+		return
+	}
+	var std, ext []string
+	for p := range imports {
+		if strings.Contains(p, ".") {
+			ext = append(ext, p)
+		} else {
+			std = append(std, p)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(ext)
+	if len(std) == 1 && len(ext) == 0 {
+		fmt.Fprintf(buf, "import %q\n\n", std[0])
+		return
+	}
+	buf.WriteString("import (\n")
+	for _, p := range std {
+		fmt.Fprintf(buf, "\t%q\n", p)
+	}
+	if len(std) > 0 && len(ext) > 0 {
+		buf.WriteString("\n")
+	}
+	for _, p := range ext {
+		if strings.HasSuffix(p, "go-mssqldb") {
+			fmt.Fprintf(buf, "\tmssql %q\n", p)
+			continue
+		}
+		fmt.Fprintf(buf, "\t%q\n", p)
+	}
+	buf.WriteString(")\n\n")
+}
+
+func writeStruct(buf *bytes.Buffer, gt *genTable) {
+	kind := "таблица"
+	if gt.View {
+		kind = "представление"
+	}
+	fmt.Fprintf(buf, "// %s %s %s.\n", gt.GoName, kind, gt.FullName())
+	fmt.Fprintf(buf, "type %s struct {\n", gt.GoName)
+	for _, f := range gt.Fields {
+		fmt.Fprintf(buf, "\t%s %s `db:%q`\n", f.GoName, f.GoType, f.Name)
+	}
+	buf.WriteString("}\n\n")
+}
+
+// writeCRUD функции чтения, вставки, изменения и удаления строки по первичному ключу.
+// Запросы формируются в синтаксисе диалекта d.
+func writeCRUD(buf *bytes.Buffer, d dbwrap.Dialect, gt *genTable) {
+	table := d.QuoteIdent(gt.FullName())
+	var (
+		all, insertCols, insertVals, sets, keyParams, keyArgs, namedWhere, where []string
+	)
+	for _, f := range gt.Fields {
+		all = append(all, d.QuoteIdent(f.Name))
+		if !f.Identity {
+			insertCols = append(insertCols, d.QuoteIdent(f.Name))
+			insertVals = append(insertVals, ":"+f.Name)
+		}
+	}
+	for i, key := range gt.PrimaryKey {
+		f := gt.field(key)
+		arg := argName(f.GoName)
+		keyParams = append(keyParams, fmt.Sprintf("%s %s", arg, strings.TrimPrefix(f.GoType, "*")))
+		keyArgs = append(keyArgs, arg)
+		where = append(where, fmt.Sprintf("%s = %s", d.QuoteIdent(key), d.Placeholder(i+1)))
+		namedWhere = append(namedWhere, fmt.Sprintf("%s = :%s", d.QuoteIdent(key), key))
+	}
+	for _, f := range gt.Fields {
+		if !f.Identity && !gt.isKey(f.Name) {
+			sets = append(sets, fmt.Sprintf("%s = :%s", d.QuoteIdent(f.Name), f.Name))
+		}
+	}
+	name, desc := gt.GoName, gt.FullName()
+
+	fmt.Fprintf(buf, "// Get%s чтение строки %s по первичному ключу.\n", name, desc)
+	fmt.Fprintf(buf, "func Get%s(ctx context.Context, db *dbwrap.DBSQL, %s) (*%s, error) {\n", name, strings.Join(keyParams, ", "), name)
+	fmt.Fprintf(buf, "\trow := &%s{}\n", name)
+	fmt.Fprintf(buf, "\tif err := db.GetContext(ctx, row, %s, %s); err != nil {\n\t\treturn nil, err\n\t}\n\treturn row, nil\n}\n\n",
+		sqlString(fmt.Sprintf("SELECT %s FROM %s WHERE %s", strings.Join(all, ", "), table, strings.Join(where, " AND "))),
+		strings.Join(keyArgs, ", "))
+
+	fmt.Fprintf(buf, "// Insert%s добавление строки %s, возвращает количество добавленных строк.\n", name, desc)
+	fmt.Fprintf(buf, "func Insert%s(ctx context.Context, db *dbwrap.DBSQL, row *%s) (int64, error) {\n", name, name)
+	fmt.Fprintf(buf, "\treturn db.NamedExecContext(ctx, %s, row)\n}\n\n",
+		sqlString(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(insertCols, ", "), strings.Join(insertVals, ", "))))
+
+	if len(sets) > 0 {
+		fmt.Fprintf(buf, "// Update%s изменение строки %s по первичному ключу, возвращает количество изменённых строк.\n", name, desc)
+		fmt.Fprintf(buf, "func Update%s(ctx context.Context, db *dbwrap.DBSQL, row *%s) (int64, error) {\n", name, name)
+		fmt.Fprintf(buf, "\treturn db.NamedExecContext(ctx, %s, row)\n}\n\n",
+			sqlString(fmt.Sprintf("UPDATE %s SET %s WHERE %s", table, strings.Join(sets, ", "), strings.Join(namedWhere, " AND "))))
+	}
+
+	fmt.Fprintf(buf, "// Delete%s удаление строки %s по первичному ключу, возвращает количество удалённых строк.\n", name, desc)
+	fmt.Fprintf(buf, "func Delete%s(ctx context.Context, db *dbwrap.DBSQL, %s) (int64, error) {\n", name, strings.Join(keyParams, ", "))
+	fmt.Fprintf(buf, "\treturn db.ExecContext(ctx, %s, %s)\n}\n\n",
+		sqlString(fmt.Sprintf("DELETE FROM %s WHERE %s", table, strings.Join(where, " AND "))),
+		strings.Join(keyArgs, ", "))
+}
+
+// sqlString строковая константа Go с текстом запроса, для mysql с `имя` - в кавычках.
+func sqlString(query string) string {
+	if strings.Contains(query, "`") {
+		return strconv.Quote(query)
+	}
+	return "`" + query + "`"
+}
+
+func (gt *genTable) field(column string) genField {
+	for _, f := range gt.Fields {
+		if strings.EqualFold(f.Name, column) {
+			return f
+		}
+	}
+	return genField{Column: &dbwrap.Column{Name: column}, GoName: goName(column), GoType: "any"}
+}
+
+func (gt *genTable) isKey(column string) bool {
+	for _, k := range gt.PrimaryKey {
+		if strings.EqualFold(k, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// argName имя параметра функции из имени поля: CustomerID -> customerID, ID -> id.
+func argName(field string) string {
+	r := []rune(field)
+	i := 0
+	for i < len(r) && unicode.IsUpper(r[i]) {
+		i++
+	}
+	if i > 1 && i < len(r) {
+		i-- // URLPath -> urlPath
+	}
+	name := strings.ToLower(string(r[:i])) + string(r[i:])
+	if isGoKeyword(name) || name == "ctx" || name == "db" || name == "row" || name == "err" {
+		name += "Arg"
+	}
+	return name
+}
+
+func isGoKeyword(s string) bool {
+	switch s {
+	case "break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough", "for",
+		"func", "go", "goto", "if", "import", "interface", "map", "package", "range", "return",
+		"select", "struct", "switch", "type", "var":
+		return true
+	}
+	return false
+}
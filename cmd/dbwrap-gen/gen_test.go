@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mpuzanov/dbwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"last_name":    "LastName",
+		"customer_id":  "CustomerID",
+		"id":           "ID",
+		"CUSTOMER_URL": "CustomerURL",
+		"orderItems":   "OrderItems",
+		"is owner":     "IsOwner",
+		"1c_code":      "X1cCode",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, goName(name), name)
+	}
+	assert.Equal(t, "customerID", argName("CustomerID"))
+	assert.Equal(t, "id", argName("ID"))
+	assert.Equal(t, "urlPath", argName("URLPath"))
+	assert.Equal(t, "typeArg", argName("Type"))
+}
+
+func TestGoType(t *testing.T) {
+	tests := []struct {
+		driver string
+		column dbwrap.Column
+		want   string
+	}{
+		{"postgres", dbwrap.Column{DataType: "character varying", Length: 50}, "string"},
+		{"postgres", dbwrap.Column{DataType: "timestamp with time zone", Nullable: true}, "*time.Time"},
+		{"postgres", dbwrap.Column{DataType: "numeric", Nullable: true}, "*float64"},
+		{"postgres", dbwrap.Column{DataType: "bytea", Nullable: true}, "[]byte"},
+		{"sqlserver", dbwrap.Column{DataType: "bit"}, "bool"},
+		{"sqlserver", dbwrap.Column{DataType: "timestamp"}, "[]byte"},
+		{"sqlserver", dbwrap.Column{DataType: "uniqueidentifier", Nullable: true}, "*mssql.UniqueIdentifier"},
+		{"mysql", dbwrap.Column{DataType: "tinyint", Length: 1}, "bool"},
+		{"mysql", dbwrap.Column{DataType: "bigint unsigned"}, "int64"},
+		{"sqlite3", dbwrap.Column{DataType: "unsigned big int"}, "int64"},
+		{"sqlite3", dbwrap.Column{DataType: ""}, "any"},
+	}
+	for _, test := range tests {
+		imports := map[string]bool{}
+		assert.Equal(t, test.want, goType(test.driver, &test.column, imports), test.column.DataType)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	db, err := dbwrap.NewConnect(dbwrap.NewConfig("sqlite3").WithDB(":memory:"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	for _, query := range []string{
+		`CREATE TABLE people (id INTEGER PRIMARY KEY, last_name VARCHAR(50) NOT NULL, birthdate DATE,
+			salary NUMERIC(10,2), is_owner_flat BOOLEAN, email TEXT NOT NULL, created_at DATETIME NOT NULL)`,
+		`CREATE TABLE flat_people (flat_id INT NOT NULL, person_id INT NOT NULL, PRIMARY KEY (flat_id, person_id))`,
+		`CREATE VIEW people_emails AS SELECT last_name, email FROM people`,
+	} {
+		_, err = db.ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+	s, err := db.Schema(ctx)
+	require.NoError(t, err)
+
+	src, err := generate(s, db.Dialect(), genOptions{Package: "models", Tables: []string{"people", "people_emails"}})
+	require.NoError(t, err)
+	assert.Equal(t, `// Code generated by dbwrap-gen. DO NOT EDIT.
+
+package models
+
+import "time"
+
+// People таблица people.
+type People struct {
+	ID          int        `+"`db:\"id\"`"+`
+	LastName    string     `+"`db:\"last_name\"`"+`
+	Birthdate   *time.Time `+"`db:\"birthdate\"`"+`
+	Salary      *float64   `+"`db:\"salary\"`"+`
+	IsOwnerFlat *bool      `+"`db:\"is_owner_flat\"`"+`
+	Email       string     `+"`db:\"email\"`"+`
+	CreatedAt   time.Time  `+"`db:\"created_at\"`"+`
+}
+
+// PeopleEmails представление people_emails.
+type PeopleEmails struct {
+	LastName *string `+"`db:\"last_name\"`"+`
+	Email    *string `+"`db:\"email\"`"+`
+}
+`, string(src))
+
+	src, err = generate(s, db.Dialect(), genOptions{Package: "models", CRUD: true})
+	require.NoError(t, err)
+	code := string(src)
+	assert.Contains(t, code, "\t\"context\"\n\t\"time\"\n\n\t\"github.com/mpuzanov/dbwrap\"\n")
+	assert.Contains(t, code, "func GetFlatPeople(ctx context.Context, db *dbwrap.DBSQL, flatID int, personID int) (*FlatPeople, error) {")
+	assert.Contains(t, code, "`SELECT \"flat_id\", \"person_id\" FROM \"flat_people\" WHERE \"flat_id\" = ? AND \"person_id\" = ?`")
+	assert.NotContains(t, code, "func UpdateFlatPeople")
+	assert.Contains(t, code, "`INSERT INTO \"people\" (\"last_name\", \"birthdate\"")
+	assert.Contains(t, code, "`UPDATE \"people\" SET \"last_name\" = :last_name")
+	assert.Contains(t, code, "func DeletePeople(ctx context.Context, db *dbwrap.DBSQL, id int) (int64, error) {")
+	assert.NotContains(t, code, "func GetPeopleEmails")
+
+	_, err = generate(s, db.Dialect(), genOptions{Tables: []string{"unknown"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Форматы вывода, csv, jsonl и xlsx выгружаются DBSQL.ExportContext по мере чтения строк.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// writeRows вывод строк JSON Lines выгрузки ExportContext в формате format, колонки в порядке запроса.
+func writeRows(w io.Writer, format string, data []byte) error {
+	lines := bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+	if len(data) == 0 {
+		lines = nil
+	}
+	switch format {
+	case formatTable:
+		return writeTable(w, lines)
+	case formatJSON:
+		return writeJSON(w, lines)
+	}
+	return fmt.Errorf("неизвестный формат %q", format)
+}
+
+// decodeRow колонки и значения объекта JSON в порядке ключей.
+func decodeRow(line []byte) (cols []string, values []any, err error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if _, err = dec.Token(); err != nil {
+		return nil, nil, err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		var v any
+		if err = dec.Decode(&v); err != nil {
+			return nil, nil, err
+		}
+		cols = append(cols, key.(string))
+		values = append(values, v)
+	}
+	return cols, values, nil
+}
+
+// writeJSON массив объектов с отступами, ключи в порядке колонок.
+func writeJSON(w io.Writer, lines [][]byte) error {
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for i, line := range lines {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n  ")
+		if err := json.Indent(&buf, line, "  ", "  "); err != nil {
+			return err
+		}
+	}
+	if len(lines) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("]\n")
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// formatValue значение колонки JSON строкой, null - представление NULL.
+func formatValue(v any, null string) string {
+	if v == nil {
+		return null
+	}
+	return fmt.Sprint(v)
+}
+
+func writeTable(w io.Writer, lines [][]byte) error {
+	var cols []string
+	rows := make([][]any, len(lines))
+	for i, line := range lines {
+		var err error
+		if cols, rows[i], err = decodeRow(line); err != nil {
+			return err
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(cols, "\t"))
+	dashes := make([]string, len(cols))
+	for i, c := range cols {
+		dashes[i] = strings.Repeat("-", len([]rune(c)))
+	}
+	fmt.Fprintln(tw, strings.Join(dashes, "\t"))
+
+	cleaner := strings.NewReplacer("\t", " ", "\n", " ", "\r", "")
+	values := make([]string, len(cols))
+	for _, row := range rows {
+		for i, v := range row {
+			values[i] = cleaner.Replace(formatValue(v, "NULL"))
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "(%d строк)\n", len(rows))
+	return err
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mpuzanov/dbwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	dbFile := filepath.Join(dir, "app.db")
+	ctx := context.Background()
+	exec := func(args ...string) string {
+		var out bytes.Buffer
+		err := run(ctx, append([]string{"-driver", "sqlite3", "-dsn", dbFile}, args...), &out)
+		require.NoError(t, err, args)
+		return out.String()
+	}
+
+	exec("-exec", "create table people (id int, last_name text, salary numeric, created_at datetime)")
+	assert.Equal(t, "(1 строк)\n", exec("-exec", "insert into people values (1, 'Иванов', 1000.5, '2024-01-02 03:04:05')"))
+	assert.Equal(t, "(1 строк)\n", exec("-p", "id=2", "-p", "name=Пётр\tПетров", "-exec", "insert into people values (:id, :name, null, null)"))
+
+	assert.Equal(t, `id  last_name    salary
+--  ---------    ------
+1   Иванов       1000.5
+2   Пётр Петров  NULL
+(2 строк)
+`, exec("select id, last_name, salary from people order by id"))
+
+	assert.Equal(t, "id;created_at;salary\n1;2024-01-02T03:04:05Z;1000.5\n2;NULL;NULL\n",
+		exec("-format", "csv", "-comma", ";", "-null", "NULL", "-p", "id=1", "select id, created_at, salary from people where id >= :id"))
+	assert.Equal(t, "{\"salary\":null,\"id\":2}\n", exec("-format", "jsonl", "select salary, id from people where id = 2"))
+	assert.Equal(t, "[\n  {\n    \"id\": 1\n  }\n]\n", exec("-format", "json", "select id from people where id = 1"))
+	// колонки в порядке запроса
+	assert.Equal(t, "salary  id\n------  --\n1000.5  1\n(1 строк)\n", exec("select salary, id from people where id = 1"))
+	assert.Equal(t, "[\n  {\n    \"salary\": null,\n    \"id\": 2\n  }\n]\n", exec("-format", "json", "select salary, id from people where id = 2"))
+	assert.Equal(t, "[]\n", exec("-format", "json", "select id from people where id = 0"))
+
+	sqlFile := filepath.Join(dir, "report.sql")
+	require.NoError(t, os.WriteFile(sqlFile, []byte("select count(*) as cnt from people"), 0o600))
+	outFile := filepath.Join(dir, "report.csv")
+	exec("-f", sqlFile, "-format", "csv", "-o", outFile)
+	data, err := os.ReadFile(outFile)
+	require.NoError(t, err)
+	assert.Equal(t, "cnt\n2\n", string(data))
+
+	xlsxFile := filepath.Join(dir, "report.xlsx")
+	exec("-f", sqlFile, "-format", "xlsx", "-o", xlsxFile)
+	_, err = zip.OpenReader(xlsxFile)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	err = run(ctx, []string{"-driver", "sqlite3", "-dsn", dbFile, "-max-rows", "1", "select * from people"}, &out)
+	assert.ErrorIs(t, err, dbwrap.ErrMaxRows)
+	err = run(ctx, []string{"-driver", "sqlite3", "-dsn", dbFile, "-max-rows", "1", "-format", "csv", "select * from people"}, &out)
+	assert.ErrorIs(t, err, dbwrap.ErrMaxRows)
+	out.Reset()
+	err = run(ctx, []string{"-driver", "sqlite3", "-dsn", dbFile, "-max-rows", "2", "-format", "csv", "select id from people order by id"}, &out)
+	require.NoError(t, err)
+	assert.Equal(t, "id\n1\n2\n", out.String())
+	err = run(ctx, []string{"-driver", "sqlite3", "-dsn", dbFile}, &out)
+	assert.EqualError(t, err, "не задан запрос: аргумент, -f или -exec")
+	err = run(ctx, []string{"-driver", "sqlite3", "-dsn", dbFile, "-timeout", time.Nanosecond.String(), "select 1"}, &out)
+	assert.Error(t, err)
+	err = run(ctx, []string{"-driver", "sqlite3", "-dsn", dbFile, "-format", "xml", "select 1"}, &out)
+	assert.Error(t, err)
+}
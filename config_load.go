@@ -0,0 +1,368 @@
+package dbwrap
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigSource источник значения параметра конфигурации.
+type ConfigSource string
+
+// Источники значений параметров конфигурации.
+const (
+	SourceDefault ConfigSource = "default" // значение из тега env-default
+	SourceFile    ConfigSource = "file"    // значение из файла YAML/JSON
+	SourceEnv     ConfigSource = "env"     // значение из переменной окружения
+)
+
+// ConfigSources источники значений по именам полей Config.
+type ConfigSources map[string]ConfigSource
+
+// LoadConfig загрузка конфигурации из файла YAML или JSON и переменных окружения.
+// Значения применяются в порядке: env-default, файл, переменные окружения. Параметры, оставшиеся
+// со значением env-default, заменяются значениями NewConfig для драйвера (порт 5432 для postgres).
+// path может быть пустым, тогда используются только переменные окружения.
+// envPrefix добавляется к именам переменных (например "BILLING_" -> BILLING_DB_HOST).
+func LoadConfig(path, envPrefix string) (*Config, ConfigSources, error) {
+	var (
+		tag    string
+		decode func(out any) error
+	)
+	if path != "" {
+		var err error
+		if tag, decode, err = readConfigFile(path); err != nil {
+			return nil, nil, err
+		}
+	}
+	return loadConfig(tag, decode, envPrefix)
+}
+
+// LoadConfigs загрузка именованных конфигураций нескольких БД.
+// В файле YAML или JSON ключи верхнего уровня - имена БД, значения - параметры Config.
+// Переменные окружения БД задаются с префиксом имени в верхнем регистре
+// (billing -> BILLING_DB_HOST). names - имена БД, которых может не быть в файле.
+func LoadConfigs(path string, names ...string) (map[string]*Config, error) {
+	sections := map[string]func(out any) error{}
+	var tag string
+	if path != "" {
+		var err error
+		if tag, sections, err = readConfigSections(path); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range names {
+		if _, ok := sections[name]; !ok {
+			sections[name] = nil
+		}
+	}
+
+	configs := make(map[string]*Config, len(sections))
+	for name, decode := range sections {
+		cfg, _, err := loadConfig(tag, decode, EnvPrefix(name))
+		if err != nil {
+			return nil, fmt.Errorf("config %s: %w", name, err)
+		}
+		configs[name] = cfg
+	}
+	return configs, nil
+}
+
+// EnvPrefix префикс переменных окружения БД с именем name в LoadConfigs.
+func EnvPrefix(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, name) + "_"
+}
+
+// loadConfig значения по умолчанию, из файла (decode может быть nil) и переменных окружения.
+func loadConfig(tag string, decode func(out any) error, envPrefix string) (*Config, ConfigSources, error) {
+	cfg := &Config{}
+	sources := ConfigSources{}
+	v := reflect.ValueOf(cfg).Elem()
+
+	if err := applyDefaults(v, "", sources); err != nil {
+		return nil, nil, err
+	}
+	if decode != nil {
+		if err := applyFile(v, tag, decode, sources); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := applyEnv(v, envPrefix, "", sources); err != nil {
+		return nil, nil, err
+	}
+	applyDriverDefaults(v, cfg.DriverName, sources)
+	return cfg, sources, nil
+}
+
+// WithDriverDefaults смена драйвера конфигурации LoadConfig (например флагом -driver): параметры,
+// не заданные в файле и переменных окружения, заменяются значениями NewConfig драйвера driverName.
+func (c *Config) WithDriverDefaults(driverName string, sources ConfigSources) *Config {
+	c.DriverName = driverName
+	applyDriverDefaults(reflect.ValueOf(c).Elem(), driverName, sources)
+	return c
+}
+
+// applyDriverDefaults значения NewConfig драйвера (порт postgres, mysql) вместо значений тегов env-default,
+// если параметр не задан в файле и переменных окружения.
+func applyDriverDefaults(v reflect.Value, driverName string, sources ConfigSources) {
+	def := reflect.ValueOf(NewConfig(driverName)).Elem()
+	for name, source := range sources {
+		if source == SourceDefault && !strings.Contains(name, ".") {
+			v.FieldByName(name).Set(def.FieldByName(name))
+		}
+	}
+}
+
+// applyDefaults значения по умолчанию из тегов env-default.
+func applyDefaults(v reflect.Value, path string, sources ConfigSources) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := path + f.Name
+		if isNestedConfig(f.Type) {
+			if err := applyDefaults(v.Field(i), name+".", sources); err != nil {
+				return err
+			}
+			continue
+		}
+		def, ok := f.Tag.Lookup("env-default")
+		if !ok {
+			continue
+		}
+		if err := setFieldString(v.Field(i), def); err != nil {
+			return fmt.Errorf("config %s default %q: %w", name, def, err)
+		}
+		sources[name] = SourceDefault
+	}
+	return nil
+}
+
+// readConfigFile чтение файла, формат определяется по расширению,
+// tag - тег полей формата, decode разбирает содержимое файла в out.
+func readConfigFile(path string) (tag string, decode func(out any) error, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("config read file: %w", err)
+	}
+	var unmarshal func(data []byte, out any) error
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		tag, unmarshal = "yaml", yaml.Unmarshal
+	case ".json":
+		tag, unmarshal = "json", json.Unmarshal
+	default:
+		return "", nil, fmt.Errorf("config file %s: неизвестный формат %q", path, ext)
+	}
+	return tag, func(out any) error {
+		if err := unmarshal(data, out); err != nil {
+			return fmt.Errorf("config parse file %s: %w", path, err)
+		}
+		return nil
+	}, nil
+}
+
+// readConfigSections чтение файла с разделами верхнего уровня.
+func readConfigSections(path string) (string, map[string]func(out any) error, error) {
+	tag, decode, err := readConfigFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	sections := map[string]func(out any) error{}
+	switch tag {
+	case "yaml":
+		var nodes map[string]yaml.Node
+		if err = decode(&nodes); err != nil {
+			return "", nil, err
+		}
+		for name, node := range nodes {
+			sections[name] = func(out any) error {
+				if err := node.Decode(out); err != nil {
+					return fmt.Errorf("config parse file %s: %w", path, err)
+				}
+				return nil
+			}
+		}
+	default:
+		var raws map[string]json.RawMessage
+		if err = decode(&raws); err != nil {
+			return "", nil, err
+		}
+		for name, raw := range raws {
+			sections[name] = func(out any) error {
+				if err := json.Unmarshal(raw, out); err != nil {
+					return fmt.Errorf("config parse file %s: %w", path, err)
+				}
+				return nil
+			}
+		}
+	}
+	return tag, sections, nil
+}
+
+// applyFile значения из файла.
+func applyFile(v reflect.Value, tag string, decode func(out any) error, sources ConfigSources) error {
+	var keys map[string]any
+	if err := decode(&keys); err != nil {
+		return err
+	}
+	if err := decode(v.Addr().Interface()); err != nil {
+		return err
+	}
+	markFileSources(v.Type(), tag, keys, "", sources)
+	return nil
+}
+
+// markFileSources отметка полей, заданных в файле.
+func markFileSources(t reflect.Type, tag string, keys map[string]any, path string, sources ConfigSources) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key := strings.Split(f.Tag.Get(tag), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+		val, ok := keys[key]
+		if !ok {
+			continue
+		}
+		name := path + f.Name
+		if nested, isMap := val.(map[string]any); isMap && isNestedConfig(f.Type) {
+			markFileSources(f.Type, tag, nested, name+".", sources)
+			continue
+		}
+		sources[name] = SourceFile
+	}
+}
+
+// applyEnv значения из переменных окружения по тегам env, вложенные структуры используют env-prefix.
+func applyEnv(v reflect.Value, prefix, path string, sources ConfigSources) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := path + f.Name
+		if isNestedConfig(f.Type) {
+			if err := applyEnv(v.Field(i), prefix+f.Tag.Get("env-prefix"), name+".", sources); err != nil {
+				return err
+			}
+			continue
+		}
+		key := f.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+		val, ok := os.LookupEnv(prefix + key)
+		if !ok {
+			continue
+		}
+		if err := setFieldString(v.Field(i), val); err != nil {
+			return fmt.Errorf("config env %s=%q: %w", prefix+key, val, err)
+		}
+		sources[name] = SourceEnv
+	}
+	return nil
+}
+
+func isNestedConfig(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{})
+}
+
+// setFieldString установка значения поля из строки.
+// Для map используется формат "key1:value1,key2:value2", для слайсов "value1,value2".
+func setFieldString(field reflect.Value, s string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	case reflect.Slice:
+		items := splitList(s)
+		slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setFieldString(slice.Index(i), item); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+	case reflect.Map:
+		m := reflect.MakeMap(field.Type())
+		for _, item := range splitList(s) {
+			k, val, ok := strings.Cut(item, ":")
+			if !ok {
+				return fmt.Errorf("ожидается key:value, получено %q", item)
+			}
+			key := reflect.New(field.Type().Key()).Elem()
+			if err := setFieldString(key, strings.TrimSpace(k)); err != nil {
+				return err
+			}
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := setFieldString(elem, strings.TrimSpace(val)); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, elem)
+		}
+		field.Set(m)
+	default:
+		return fmt.Errorf("неподдерживаемый тип %s", field.Type())
+	}
+	return nil
+}
+
+func splitList(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	items := strings.Split(s, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	return items
+}
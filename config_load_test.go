@@ -0,0 +1,130 @@
+package dbwrap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "db.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte("driver_name: postgres\nhost: db.local\nport: 5432\ndatabase: app\n"), 0o600))
+	jsonFile := filepath.Join(dir, "db.json")
+	require.NoError(t, os.WriteFile(jsonFile, []byte(`{"host": "json.local", "user": "app"}`), 0o600))
+
+	t.Setenv("DB_USER", "env_user")
+	t.Setenv("BILLING_DB_HOST", "billing.local")
+	t.Setenv("BILLING_TIMEOUT_QUERY", "60")
+
+	cfg, sources, err := LoadConfig(yamlFile, "")
+	require.NoError(t, err)
+	assert.Equal(t, "postgres", cfg.DriverName)
+	assert.Equal(t, "db.local", cfg.Host)
+	assert.Equal(t, 5432, cfg.Port)
+	assert.Equal(t, "env_user", cfg.User)
+	assert.Equal(t, 300, cfg.TimeoutQuery)
+	assert.Equal(t, SourceFile, sources["Host"])
+	assert.Equal(t, SourceEnv, sources["User"])
+	assert.Equal(t, SourceDefault, sources["TimeoutQuery"])
+	assert.NotContains(t, sources, "Password")
+
+	cfg, sources, err = LoadConfig(jsonFile, "BILLING_")
+	require.NoError(t, err)
+	assert.Equal(t, "sqlserver", cfg.DriverName)
+	assert.Equal(t, 1433, cfg.Port)
+	assert.Equal(t, "billing.local", cfg.Host)
+	assert.Equal(t, "app", cfg.User)
+	assert.Equal(t, 60, cfg.TimeoutQuery)
+	assert.Equal(t, SourceEnv, sources["Host"])
+	assert.Equal(t, SourceFile, sources["User"])
+
+	t.Setenv("DB_PORT", "port")
+	_, _, err = LoadConfig("", "")
+	assert.Error(t, err)
+
+	_, _, err = LoadConfig(filepath.Join(dir, "db.toml"), "")
+	assert.Error(t, err)
+}
+
+func TestLoadConfigDriverDefaults(t *testing.T) {
+	dir := t.TempDir()
+	for driver, port := range map[string]int{"postgres": 5432, "mysql": 3306, "sqlserver": 1433, "sqlite3": 0} {
+		file := filepath.Join(dir, driver+".yaml")
+		require.NoError(t, os.WriteFile(file, []byte("driver_name: "+driver+"\nhost: db.local\n"), 0o600))
+		cfg, sources, err := LoadConfig(file, "")
+		require.NoError(t, err)
+		assert.Equal(t, port, cfg.Port, driver)
+		assert.Equal(t, 300, cfg.TimeoutQuery, driver)
+		assert.Equal(t, SourceDefault, sources["Port"], driver)
+	}
+
+	// порт из переменной окружения не заменяется
+	t.Setenv("DB_PORT", "6432")
+	cfg, _, err := LoadConfig(filepath.Join(dir, "postgres.yaml"), "")
+	require.NoError(t, err)
+	assert.Equal(t, 6432, cfg.Port)
+
+	t.Setenv("DB_DRIVER_NAME", "mysql")
+	os.Unsetenv("DB_PORT")
+	cfg, _, err = LoadConfig("", "")
+	require.NoError(t, err)
+	assert.Equal(t, 3306, cfg.Port)
+
+	// драйвер задан после загрузки
+	os.Unsetenv("DB_DRIVER_NAME")
+	t.Setenv("DB_HOST", "db.local")
+	cfg, sources, err := LoadConfig("", "")
+	require.NoError(t, err)
+	assert.Equal(t, 1433, cfg.Port)
+	cfg.WithDriverDefaults("postgres", sources)
+	assert.Equal(t, "postgres", cfg.DriverName)
+	assert.Equal(t, 5432, cfg.Port)
+	assert.Equal(t, "db.local", cfg.Host)
+	// порт из файла сохраняется
+	file := filepath.Join(dir, "port.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("port: 6432\n"), 0o600))
+	cfg, sources, err = LoadConfig(file, "")
+	require.NoError(t, err)
+	assert.Equal(t, 6432, cfg.WithDriverDefaults("postgres", sources).Port)
+}
+
+func TestLoadConfigParams(t *testing.T) {
+	t.Setenv("DB_PARAMS", "_journal_mode:WAL, _busy_timeout:5000")
+	cfg, sources, err := LoadConfig("", "")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"_journal_mode": "WAL", "_busy_timeout": "5000"}, cfg.Params)
+	assert.Equal(t, SourceEnv, sources["Params"])
+}
+
+func TestLoadConfigs(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`billing:
+  driver_name: sqlserver
+  host: billing.local
+app:
+  driver_name: postgres
+  port: 5432
+cache:
+  driver_name: sqlite3
+  sqlite:
+    journal_mode: WAL
+`), 0o600))
+	t.Setenv("APP_DB_HOST", "app.local")
+	t.Setenv("REPORTS_DB_DRIVER_NAME", "mysql")
+
+	configs, err := LoadConfigs(file, "reports")
+	require.NoError(t, err)
+	assert.Len(t, configs, 4)
+	assert.Equal(t, "billing.local", configs["billing"].Host)
+	assert.Equal(t, 1433, configs["billing"].Port)
+	assert.Equal(t, "app.local", configs["app"].Host)
+	assert.Equal(t, 5432, configs["app"].Port)
+	assert.Equal(t, "WAL", configs["cache"].SQLite.JournalMode)
+	assert.Equal(t, "mysql", configs["reports"].DriverName)
+
+	assert.Equal(t, "BILLING_DB_", EnvPrefix("billing-db"))
+}
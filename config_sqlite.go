@@ -0,0 +1,130 @@
+package dbwrap
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SQLiteOptions параметры БД SQLite (драйвер mattn/go-sqlite3).
+type SQLiteOptions struct {
+	JournalMode string `json:"journal_mode" yaml:"journal_mode" env:"JOURNAL_MODE"` // DELETE, TRUNCATE, PERSIST, MEMORY, WAL, OFF
+	BusyTimeout int    `json:"busy_timeout" yaml:"busy_timeout" env:"BUSY_TIMEOUT"` // ожидание блокировки, миллисекунды
+	ForeignKeys bool   `json:"foreign_keys" yaml:"foreign_keys" env:"FOREIGN_KEYS"` // проверка внешних ключей
+	ReadOnly    bool   `json:"read_only" yaml:"read_only" env:"READ_ONLY"`          // открыть файл только для чтения
+	SharedCache bool   `json:"shared_cache" yaml:"shared_cache" env:"SHARED_CACHE"` // общий кэш, для :memory: одна БД на все соединения
+}
+
+var sqliteJournalModes = []string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}
+
+// sqliteMemory БД SQLite в памяти.
+func (c *Config) sqliteMemory() bool {
+	return c.Database == "" || c.Database == ":memory:" || c.Params["mode"] == "memory"
+}
+
+// sqliteDSN строка подключения SQLite, при заданных параметрах в формате file: URI.
+func (c *Config) sqliteDSN() string {
+	name := c.Database
+	if name == "" {
+		name = ":memory:"
+	}
+
+	v := url.Values{}
+	o := c.SQLite
+	if o.JournalMode != "" {
+		v.Set("_journal_mode", strings.ToUpper(o.JournalMode))
+	}
+	if o.BusyTimeout > 0 {
+		v.Set("_busy_timeout", strconv.Itoa(o.BusyTimeout))
+	}
+	if o.ForeignKeys {
+		v.Set("_foreign_keys", "1")
+	}
+	if o.ReadOnly {
+		v.Set("mode", "ro")
+	}
+	if o.SharedCache {
+		v.Set("cache", "shared")
+	}
+	for key, val := range c.Params {
+		v.Set(key, val)
+	}
+	if len(v) == 0 {
+		return name
+	}
+
+	if !strings.HasPrefix(name, "file:") {
+		name = "file:" + name
+	}
+	sep := "?"
+	if strings.Contains(name, "?") {
+		sep = "&"
+	}
+	return name + sep + v.Encode()
+}
+
+// setSQLiteParam параметр строки подключения SQLite в SQLiteOptions или Params.
+func (c *Config) setSQLiteParam(key, value string) {
+	switch {
+	case key == "_journal_mode" || key == "_journal":
+		c.SQLite.JournalMode = value
+		return
+	case key == "_busy_timeout" || key == "_timeout":
+		if n, err := strconv.Atoi(value); err == nil {
+			c.SQLite.BusyTimeout = n
+			return
+		}
+	case key == "_foreign_keys" || key == "_fk":
+		if b, err := strconv.ParseBool(value); err == nil && b {
+			c.SQLite.ForeignKeys = true
+			return
+		}
+	case key == "mode" && value == "ro":
+		c.SQLite.ReadOnly = true
+		return
+	case key == "cache" && value == "shared":
+		c.SQLite.SharedCache = true
+		return
+	}
+	c.addParam(key, value)
+}
+
+// validateSQLite проверка параметров SQLite.
+func (c *Config) validateSQLite(add func(field string, value any, err error)) {
+	o := c.SQLite
+	if o.JournalMode != "" && !slices.Contains(sqliteJournalModes, strings.ToUpper(o.JournalMode)) {
+		add("SQLite.JournalMode", o.JournalMode, fmt.Errorf("%w, допустимо: %s", ErrInvalidValue, strings.Join(sqliteJournalModes, ", ")))
+	}
+	if o.BusyTimeout < 0 {
+		add("SQLite.BusyTimeout", o.BusyTimeout, ErrInvalidValue)
+	}
+	if o.ReadOnly && c.sqliteMemory() {
+		add("SQLite.ReadOnly", o.ReadOnly, fmt.Errorf("%w: БД в памяти не может быть только для чтения", ErrInvalidValue))
+	}
+}
+
+// setSQLitePool ограничения пула соединений SQLite:
+// каждое соединение к :memory: без общего кэша открывает отдельную пустую БД,
+// общая БД в памяти существует, пока открыто хотя бы одно соединение,
+// в файл с явно заданным журналом не WAL (SQLite.JournalMode) одновременно пишет только одно соединение,
+// без JournalMode пул файла не ограничивается.
+func (c *Config) setSQLitePool(db *sql.DB) {
+	if c.DriverName != "sqlite3" || c.DSN != "" {
+		return
+	}
+	switch {
+	case c.sqliteMemory() && !c.SQLite.SharedCache:
+		db.SetMaxOpenConns(1)
+		db.SetConnMaxLifetime(0)
+		db.SetConnMaxIdleTime(0)
+	case c.sqliteMemory():
+		db.SetMaxIdleConns(2)
+		db.SetConnMaxLifetime(0)
+		db.SetConnMaxIdleTime(0)
+	case !c.SQLite.ReadOnly && c.SQLite.JournalMode != "" && !strings.EqualFold(c.SQLite.JournalMode, "WAL"):
+		db.SetMaxOpenConns(1)
+	}
+}
@@ -0,0 +1,52 @@
+package dbwrap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteOptions(t *testing.T) {
+	var tests = []struct {
+		in   Config
+		want string
+	}{
+		{Config{DriverName: "sqlite3", Database: "/data/app.db"}, "/data/app.db"},
+		{Config{DriverName: "sqlite3", Database: "/data/app.db",
+			SQLite: SQLiteOptions{JournalMode: "wal", BusyTimeout: 5000, ForeignKeys: true}},
+			"file:/data/app.db?_busy_timeout=5000&_foreign_keys=1&_journal_mode=WAL"},
+		{Config{DriverName: "sqlite3", Database: "/data/app.db", SQLite: SQLiteOptions{ReadOnly: true},
+			Params: map[string]string{"_busy_timeout": "100"}},
+			"file:/data/app.db?_busy_timeout=100&mode=ro"},
+		{Config{DriverName: "sqlite3", SQLite: SQLiteOptions{SharedCache: true}}, "file::memory:?cache=shared"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, test.in.GetDatabaseURL())
+	}
+
+	parsed, err := ParseDSN("sqlite3", tests[1].want)
+	require.NoError(t, err)
+	assert.Equal(t, tests[1].in.SQLite.BusyTimeout, parsed.SQLite.BusyTimeout)
+	assert.True(t, parsed.SQLite.ForeignKeys)
+	assert.Empty(t, parsed.Params)
+
+	cfg := NewConfig("sqlite3")
+	cfg.SQLite = SQLiteOptions{JournalMode: "fast", BusyTimeout: -1, ReadOnly: true}
+	fields := map[string]bool{}
+	for _, fe := range FieldErrors(cfg.Validate()) {
+		fields[fe.Field] = true
+	}
+	assert.Equal(t, map[string]bool{"SQLite.JournalMode": true, "SQLite.BusyTimeout": true, "SQLite.ReadOnly": true}, fields)
+}
+
+func TestLoadConfigSQLite(t *testing.T) {
+	t.Setenv("DB_DRIVER_NAME", "sqlite3")
+	t.Setenv("DB_SQLITE_JOURNAL_MODE", "WAL")
+	t.Setenv("DB_SQLITE_BUSY_TIMEOUT", "2000")
+	t.Setenv("DB_SQLITE_FOREIGN_KEYS", "true")
+	cfg, sources, err := LoadConfig("", "")
+	require.NoError(t, err)
+	assert.Equal(t, SQLiteOptions{JournalMode: "WAL", BusyTimeout: 2000, ForeignKeys: true}, cfg.SQLite)
+	assert.Equal(t, SourceEnv, sources["SQLite.JournalMode"])
+}
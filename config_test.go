@@ -28,3 +28,56 @@ func TestConfigString(t *testing.T) {
 	got := NewConfig("sqlserver").String()
 	assert.Equal(t, expected, got)
 }
+
+func TestGetDatabaseUrlMySQL(t *testing.T) {
+
+	var tests = []struct {
+		in   Config
+		want string
+	}{
+		{Config{Host: "host", Port: 3307, Database: "db", User: "user", Password: "password", DriverName: "mysql", Timezone: "UTC"},
+			"user:password@tcp(host:3307)/db?parseTime=true"},
+		{Config{Host: "host", Database: "db", User: "user", DriverName: "mysql", Timezone: "Europe/Moscow", Collation: "utf8mb4_unicode_ci"},
+			"user@tcp(host:3306)/db?collation=utf8mb4_unicode_ci&loc=Europe%2FMoscow&parseTime=true"},
+		{Config{Socket: "/var/run/mysqld/mysqld.sock", Database: "db", User: "user", DriverName: "mysql", Timezone: "UTC",
+			Params: map[string]string{"tls": "skip-verify", "charset": "utf8mb4", "timeout": "5s", "parseTime": "false"}},
+			"user@unix(/var/run/mysqld/mysqld.sock)/db?timeout=5s&tls=skip-verify&charset=utf8mb4"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, test.in.GetDatabaseURL())
+	}
+
+	cfg := Config{Host: "host", Database: "db", User: "user", DriverName: "mysql", Params: map[string]string{"parseTime": "maybe"}}
+	assert.ErrorIs(t, cfg.Validate(), ErrInvalidValue)
+}
+
+func TestGetDatabaseUrlParams(t *testing.T) {
+
+	var tests = []struct {
+		in   Config
+		want string
+	}{
+		{Config{Host: "host", Port: 1433, Database: "db", User: "user", DriverName: "sqlserver",
+			Params: map[string]string{"connection timeout": "30", "log": "1"}},
+			"sqlserver://user:@host:1433?connection+timeout=30&database=db&log=1"},
+		{Config{Host: "host", Port: 5432, Database: "db", User: "user", DriverName: "postgres",
+			Params: map[string]string{"search_path": "app", "application_name": "svc"}},
+			"postgres://user:@host:5432?application_name=svc&database=db&search_path=app&sslmode=disable"},
+		{Config{Database: "/data/app.db", DriverName: "sqlite3",
+			Params: map[string]string{"_journal_mode": "WAL", "_busy_timeout": "5000"}},
+			"file:/data/app.db?_busy_timeout=5000&_journal_mode=WAL"},
+		{Config{DriverName: "sqlite3", Params: map[string]string{"cache": "shared"}}, "file::memory:?cache=shared"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, test.in.GetDatabaseURL())
+	}
+
+	cfg := Config{DriverName: "sqlite3", Params: map[string]string{"_journal_mod": "WAL"}}
+	errs := FieldErrors(cfg.Validate())
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "Params", errs[0].Field)
+
+	cfg = Config{Host: "host", Port: 5432, Database: "db", User: "user", DriverName: "postgres",
+		Params: map[string]string{"search_path": "app"}}
+	assert.NoError(t, cfg.Validate())
+}
@@ -0,0 +1,177 @@
+package dbwrap
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Режимы SSLMode, названия как у sslmode PostgreSQL.
+const (
+	SSLDisable    = "disable"     // без шифрования
+	SSLAllow      = "allow"       // шифрование, если поддерживается сервером
+	SSLPrefer     = "prefer"      // шифрование, если поддерживается сервером
+	SSLRequire    = "require"     // шифрование без проверки сертификата сервера
+	SSLVerifyCA   = "verify-ca"   // проверка цепочки сертификата сервера по SSLRootCert
+	SSLVerifyFull = "verify-full" // проверка цепочки и имени сервера
+)
+
+var sslModes = []string{SSLDisable, SSLAllow, SSLPrefer, SSLRequire, SSLVerifyCA, SSLVerifyFull}
+
+// TLSConfig настройки TLS по полям SSL*, nil при SSLMode disable или не заданном.
+// Используется для mysql, для postgres и sqlserver параметры передаются в строке подключения.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	if c.SSLMode == "" || c.SSLMode == SSLDisable {
+		return nil, nil
+	}
+	tc := &tls.Config{ServerName: c.SSLServerName, MinVersion: tls.VersionTLS12}
+	if tc.ServerName == "" {
+		tc.ServerName = c.Host
+	}
+
+	if c.SSLRootCert != "" {
+		pem, err := os.ReadFile(c.SSLRootCert)
+		if err != nil {
+			return nil, fmt.Errorf("ssl root cert: %w", err)
+		}
+		tc.RootCAs = x509.NewCertPool()
+		if !tc.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ssl root cert %s: нет сертификатов в формате PEM", c.SSLRootCert)
+		}
+	}
+	if c.SSLCert != "" || c.SSLKey != "" {
+		cert, err := tls.LoadX509KeyPair(c.SSLCert, c.SSLKey)
+		if err != nil {
+			return nil, fmt.Errorf("ssl client cert: %w", err)
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+
+	switch c.SSLMode {
+	case SSLVerifyFull:
+	case SSLVerifyCA:
+		// проверка цепочки без проверки имени сервера
+		tc.InsecureSkipVerify = true
+		roots := tc.RootCAs
+		tc.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyChain(rawCerts, roots)
+		}
+	default:
+		tc.InsecureSkipVerify = true
+	}
+	return tc, nil
+}
+
+func verifyChain(rawCerts [][]byte, roots *x509.CertPool) error {
+	if len(rawCerts) == 0 {
+		return errors.New("tls: сервер не передал сертификат")
+	}
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		certs[i] = cert
+	}
+	opts := x509.VerifyOptions{Roots: roots, Intermediates: x509.NewCertPool()}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(opts)
+	return err
+}
+
+// mysqlTLSName имя настроек TLS для mysql: стандартное значение параметра tls
+// или имя конфигурации, регистрируемой через mysql.RegisterTLSConfig.
+func (c *Config) mysqlTLSName() (name string, custom bool) {
+	switch c.SSLMode {
+	case "":
+		return "", false
+	case SSLDisable:
+		return "false", false
+	case SSLAllow, SSLPrefer:
+		if c.SSLRootCert == "" && c.SSLCert == "" {
+			return "preferred", false
+		}
+	case SSLRequire:
+		if c.SSLRootCert == "" && c.SSLCert == "" {
+			return "skip-verify", false
+		}
+	}
+	h := sha256.New()
+	for _, s := range []string{c.SSLMode, c.SSLRootCert, c.SSLCert, c.SSLKey, c.SSLServerName, c.Host} {
+		h.Write([]byte(s + "\x00"))
+	}
+	return "dbwrap-" + hex.EncodeToString(h.Sum(nil))[:16], true
+}
+
+// registerTLS регистрация настроек TLS в драйвере mysql.
+func (c *Config) registerTLS() error {
+	if c.DriverName != "mysql" || c.DSN != "" {
+		return nil
+	}
+	name, custom := c.mysqlTLSName()
+	if !custom {
+		return nil
+	}
+	tc, err := c.TLSConfig()
+	if err != nil {
+		return err
+	}
+	return mysql.RegisterTLSConfig(name, tc)
+}
+
+// setPostgresSSL параметры sslmode, sslrootcert, sslcert, sslkey.
+func (c *Config) setPostgresSSL(v url.Values) {
+	mode := c.SSLMode
+	if mode == "" {
+		mode = SSLDisable
+	}
+	v.Set("sslmode", mode)
+	if c.SSLRootCert != "" {
+		v.Set("sslrootcert", c.SSLRootCert)
+	}
+	if c.SSLCert != "" {
+		v.Set("sslcert", c.SSLCert)
+	}
+	if c.SSLKey != "" {
+		v.Set("sslkey", c.SSLKey)
+	}
+}
+
+// setSQLServerSSL параметры encrypt, TrustServerCertificate, certificate, hostNameInCertificate.
+func (c *Config) setSQLServerSSL(v url.Values) {
+	switch c.SSLMode {
+	case "":
+		if c.Encrypt != "" {
+			v.Set("encrypt", c.Encrypt)
+		}
+		return
+	case SSLDisable:
+		v.Set("encrypt", "disable")
+		return
+	case SSLAllow, SSLPrefer:
+		v.Set("encrypt", "false")
+	case SSLRequire:
+		v.Set("encrypt", "true")
+		v.Set("TrustServerCertificate", "true")
+		return
+	default:
+		v.Set("encrypt", "true")
+		v.Set("TrustServerCertificate", "false")
+	}
+	if c.SSLRootCert != "" {
+		v.Set("certificate", c.SSLRootCert)
+	}
+	if c.SSLServerName != "" {
+		v.Set("hostNameInCertificate", c.SSLServerName)
+	}
+}
@@ -0,0 +1,97 @@
+package dbwrap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSelfSignedCert самоподписанный сертификат и ключ в каталоге dir.
+func writeSelfSignedCert(t *testing.T, dir, name string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	return certFile, keyFile
+}
+
+func TestGetDatabaseUrlSSL(t *testing.T) {
+	dir := t.TempDir()
+	caFile, _ := writeSelfSignedCert(t, dir, "ca")
+	certFile, keyFile := writeSelfSignedCert(t, dir, "client")
+
+	pg := Config{DriverName: "postgres", Host: "host", Port: 5432, Database: "db", User: "user",
+		SSLMode: SSLVerifyFull, SSLRootCert: caFile, SSLCert: certFile, SSLKey: keyFile}
+	assert.NoError(t, pg.Validate())
+	got := pg.GetDatabaseURL()
+	assert.Contains(t, got, "sslmode=verify-full")
+	assert.Contains(t, got, "sslrootcert="+strings.ReplaceAll(caFile, "/", "%2F"))
+	assert.Contains(t, got, "sslkey=")
+	assert.Contains(t, NewConfig("postgres").GetDatabaseURL(), "sslmode=disable")
+
+	ms := Config{DriverName: "sqlserver", Host: "host", Port: 1433, Database: "db", User: "user",
+		SSLMode: SSLVerifyCA, SSLRootCert: caFile, SSLServerName: "db.example.com"}
+	assert.NoError(t, ms.Validate())
+	got = ms.GetDatabaseURL()
+	assert.Contains(t, got, "encrypt=true")
+	assert.Contains(t, got, "TrustServerCertificate=false")
+	assert.Contains(t, got, "certificate=")
+	assert.Contains(t, got, "hostNameInCertificate=db.example.com")
+	ms = Config{DriverName: "sqlserver", Host: "host", Port: 1433, Database: "db", User: "user", SSLMode: SSLRequire}
+	assert.Equal(t, "sqlserver://user:@host:1433?TrustServerCertificate=true&database=db&encrypt=true", ms.GetDatabaseURL())
+
+	my := Config{DriverName: "mysql", Host: "host", Port: 3306, Database: "db", User: "user", Timezone: "UTC", SSLMode: SSLRequire}
+	assert.Equal(t, "user@tcp(host:3306)/db?parseTime=true&tls=skip-verify", my.GetDatabaseURL())
+	my.SSLMode = SSLVerifyFull
+	my.SSLRootCert = caFile
+	my.SSLCert, my.SSLKey = certFile, keyFile
+	name, custom := my.mysqlTLSName()
+	assert.True(t, custom)
+	assert.Contains(t, my.GetDatabaseURL(), "tls="+name)
+	require.NoError(t, my.registerTLS())
+
+	tc, err := my.TLSConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "host", tc.ServerName)
+	assert.False(t, tc.InsecureSkipVerify)
+	assert.NotNil(t, tc.RootCAs)
+	assert.Len(t, tc.Certificates, 1)
+
+	bad := Config{DriverName: "sqlserver", Host: "host", Port: 1433, Database: "db", User: "user",
+		SSLMode: "full", SSLCert: certFile, SSLRootCert: filepath.Join(dir, "missing.crt")}
+	fields := map[string]bool{}
+	for _, fe := range FieldErrors(bad.Validate()) {
+		fields[fe.Field] = true
+	}
+	assert.Equal(t, map[string]bool{"SSLMode": true, "SSLCert": true, "SSLRootCert": true}, fields)
+}
@@ -0,0 +1,223 @@
+package dbwrap
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// Ошибки проверки конфигурации, доступны через errors.Is.
+var (
+	ErrUnknownDriver = errors.New("неизвестный драйвер БД")
+	ErrInvalidValue  = errors.New("недопустимое значение")
+	ErrConflictDSN   = errors.New("параметр не используется при заданной строке подключения DSN")
+)
+
+// FieldError ошибка значения поля Config.
+type FieldError struct {
+	Field string // имя поля Config
+	Value any    // значение поля, для Password не заполняется
+	Err   error
+}
+
+// Error текст ошибки.
+func (e *FieldError) Error() string {
+	if e.Value == nil {
+		return fmt.Sprintf("config %s: %v", e.Field, e.Err)
+	}
+	return fmt.Sprintf("config %s=%v: %v", e.Field, e.Value, e.Err)
+}
+
+// Unwrap исходная ошибка.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// FieldErrors список ошибок полей из ошибки Validate.
+func FieldErrors(err error) []*FieldError {
+	var res []*FieldError
+	for _, e := range multierr.Errors(err) {
+		var fe *FieldError
+		if errors.As(e, &fe) {
+			res = append(res, fe)
+		}
+	}
+	return res
+}
+
+// knownParams известные параметры строки подключения драйверов, для postgres и mysql
+// неизвестные параметры передаются серверу как параметры сеанса и не проверяются.
+var knownParams = map[string][]string{
+	"sqlserver": {
+		"database", "encrypt", "password", "change password", "user id", "port", "trustservercertificate",
+		"certificate", "tlsmin", "packet size", "log", "connection timeout", "hostnameincertificate",
+		"keepalive", "serverspn", "workstation id", "app name", "applicationintent", "failoverpartner",
+		"failoverport", "disableretry", "server", "protocol", "dial timeout", "pipe", "multisubnetfailover",
+		"notraceid", "guid conversion", "timezone", "fedauth", "resource id",
+	},
+	"sqlite3": {
+		"_loc", "_auth", "_auth_user", "_auth_pass", "_auth_crypt", "_auth_salt", "_mutex", "_txlock",
+		"_busy_timeout", "_timeout", "_journal_mode", "_journal", "_foreign_keys", "_fk",
+		"_locking_mode", "_locking", "_query_only", "_recursive_triggers", "_rt", "_secure_delete",
+		"_synchronous", "_sync", "_defer_foreign_keys", "_defer_fk", "_case_sensitive_like", "_cslike",
+		"_ignore_check_constraints", "_writable_schema", "_cache_size", "_auto_vacuum", "_vacuum",
+		"vfs", "cache", "mode", "immutable", "nolock", "psow",
+	},
+}
+
+var sqlserverEncrypt = []string{"true", "false", "disable", "strict", "mandatory", "optional", "yes", "no", "0", "1"}
+
+// Validate проверка параметров подключения, возвращает все найденные ошибки
+// (multierr, отдельные ошибки доступны через FieldErrors).
+// Ошибки незаполненных обязательных полей соответствуют ErrBadConfigDB.
+func (c *Config) Validate() error {
+	var errs error
+	add := func(field string, value any, err error) {
+		errs = multierr.Append(errs, &FieldError{Field: field, Value: value, Err: err})
+	}
+
+	switch {
+	case c.DriverName == "":
+		add("DriverName", nil, ErrBadConfigDB)
+	case !isKnownDriver(c.DriverName):
+		add("DriverName", c.DriverName, ErrUnknownDriver)
+	}
+
+	if c.TimeoutQuery < 0 {
+		add("TimeoutQuery", c.TimeoutQuery, ErrInvalidValue)
+	}
+
+	if c.DriverName == "sqlserver" && c.Encrypt != "" && !slices.Contains(sqlserverEncrypt, strings.ToLower(c.Encrypt)) {
+		add("Encrypt", c.Encrypt, fmt.Errorf("%w, допустимо: %s", ErrInvalidValue, strings.Join(sqlserverEncrypt, ", ")))
+	}
+
+	errs = multierr.Append(errs, c.validateSSL())
+
+	if known, ok := knownParams[c.DriverName]; ok {
+		for _, key := range slices.Sorted(maps.Keys(c.Params)) {
+			if !slices.Contains(known, strings.ToLower(key)) {
+				add("Params", key, fmt.Errorf("%w: неизвестный параметр драйвера %s", ErrInvalidValue, c.DriverName))
+			}
+		}
+	}
+
+	c.validateReplicas(add)
+
+	if c.DSN != "" {
+		if c.Password != "" {
+			add("Password", nil, ErrConflictDSN)
+		}
+		if c.APPName != "" {
+			add("APPName", c.APPName, ErrConflictDSN)
+		}
+		if c.Encrypt != "" {
+			add("Encrypt", c.Encrypt, ErrConflictDSN)
+		}
+		if !isKnownDriver(c.DriverName) {
+			return errs
+		}
+		parsed, err := ParseDSN(c.DriverName, c.DSN)
+		if err != nil {
+			if !errors.Is(err, ErrUnknownDriver) {
+				add("DSN", nil, err)
+			}
+			return errs
+		}
+		for _, fe := range FieldErrors(parsed.Validate()) {
+			fe.Field = "DSN." + fe.Field
+			errs = multierr.Append(errs, fe)
+		}
+		return errs
+	}
+
+	if c.DriverName == "sqlite3" {
+		c.validateSQLite(add)
+		if dir, ok := sqliteDir(c.Database); ok {
+			if st, err := os.Stat(dir); err != nil || !st.IsDir() {
+				add("Database", c.Database, fmt.Errorf("%w: каталог %s не существует", ErrInvalidValue, dir))
+			}
+		}
+		return errs
+	}
+
+	socket := c.DriverName == "mysql" && c.Socket != ""
+	if c.Host == "" && !socket {
+		add("Host", nil, ErrBadConfigDB)
+	}
+	if c.Database == "" {
+		add("Database", nil, ErrBadConfigDB)
+	}
+	if c.User == "" {
+		add("User", nil, ErrBadConfigDB)
+	}
+	if c.DriverName == "mysql" {
+		if _, err := c.mysqlConfig(); err != nil {
+			add("Params", c.Params, fmt.Errorf("%w: %w", ErrInvalidValue, err))
+		}
+	}
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			add("Timezone", c.Timezone, fmt.Errorf("%w: %w", ErrInvalidValue, err))
+		}
+	}
+	// при подключении через unix socket порт не используется
+	if (c.Port < 1 || c.Port > 65535) && !socket {
+		add("Port", c.Port, fmt.Errorf("%w, допустимо 1-65535", ErrInvalidValue))
+	}
+	return errs
+}
+
+func isKnownDriver(name string) bool {
+	if _, ok := GetDialect(name); ok {
+		return true
+	}
+	return slices.Contains(sql.Drivers(), name)
+}
+
+// sqliteDir каталог файла БД sqlite, ok=false для БД в памяти.
+func sqliteDir(database string) (string, bool) {
+	name := strings.TrimPrefix(database, "file:")
+	if i := strings.IndexByte(name, '?'); i >= 0 {
+		name = name[:i]
+	}
+	if name == "" || name == ":memory:" || strings.Contains(database, "mode=memory") {
+		return "", false
+	}
+	return filepath.Dir(name), true
+}
+
+func (c *Config) validateSSL() error {
+	var errs error
+	add := func(field string, value any, err error) {
+		errs = multierr.Append(errs, &FieldError{Field: field, Value: value, Err: err})
+	}
+	if c.SSLMode != "" && !slices.Contains(sslModes, c.SSLMode) {
+		add("SSLMode", c.SSLMode, fmt.Errorf("%w, допустимо: %s", ErrInvalidValue, strings.Join(sslModes, ", ")))
+	}
+	if (c.SSLCert == "") != (c.SSLKey == "") {
+		add("SSLCert", c.SSLCert, fmt.Errorf("%w: сертификат и ключ клиента задаются вместе", ErrInvalidValue))
+	}
+	if c.DriverName == "sqlserver" && c.SSLCert != "" {
+		add("SSLCert", c.SSLCert, fmt.Errorf("%w: сертификат клиента не поддерживается драйвером sqlserver", ErrInvalidValue))
+	}
+	files := []struct{ field, name string }{
+		{"SSLRootCert", c.SSLRootCert}, {"SSLCert", c.SSLCert}, {"SSLKey", c.SSLKey},
+	}
+	for _, f := range files {
+		if f.name == "" {
+			continue
+		}
+		if _, err := os.Stat(f.name); err != nil {
+			add(f.field, f.name, fmt.Errorf("%w: %w", ErrInvalidValue, err))
+		}
+	}
+	return errs
+}
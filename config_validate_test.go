@@ -0,0 +1,40 @@
+package dbwrap
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, NewConfig("sqlserver").Validate())
+	assert.NoError(t, NewConfig("postgres").Validate())
+	assert.NoError(t, NewConfig("sqlite3").Validate())
+	assert.NoError(t, NewConfig("sqlite3").WithDB(filepath.Join(t.TempDir(), "test.db")).Validate())
+	assert.NoError(t, NewConfig("mysql").WithDSN("root:123@tcp(127.0.0.1:3306)/mysql").Validate())
+
+	cfg := NewConfig("sqlserver").WithPort(0)
+	cfg.User = ""
+	cfg.TimeoutQuery = -1
+	cfg.Encrypt = "maybe"
+	err := cfg.Validate()
+	assert.ErrorIs(t, err, ErrBadConfigDB)
+	assert.ErrorIs(t, err, ErrInvalidValue)
+	fields := map[string]bool{}
+	for _, fe := range FieldErrors(err) {
+		fields[fe.Field] = true
+	}
+	assert.Equal(t, map[string]bool{"Port": true, "User": true, "TimeoutQuery": true, "Encrypt": true}, fields)
+
+	err = NewConfig("oracle").Validate()
+	assert.ErrorIs(t, err, ErrUnknownDriver)
+
+	err = NewConfig("postgres").WithPassword("secret").WithDSN("postgres://localhost/db").Validate()
+	assert.ErrorIs(t, err, ErrConflictDSN)
+	assert.NotContains(t, err.Error(), "secret")
+
+	err = NewConfig("sqlite3").WithDB("/nonexistent/dbwrap/test.db").Validate()
+	assert.ErrorIs(t, err, ErrInvalidValue)
+	assert.Len(t, FieldErrors(err), 1)
+}
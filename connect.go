@@ -0,0 +1,148 @@
+package dbwrap
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/multierr"
+)
+
+// Option параметр создания подключения к БД.
+type Option func(*options)
+
+type options struct {
+	ctx        context.Context
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	maxWait    time.Duration
+	logger     *slog.Logger
+
+	lazy           bool
+	healthInterval time.Duration
+	onHealthChange HealthFunc
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		ctx:        context.Background(),
+		attempts:   1,
+		backoff:    time.Second,
+		maxBackoff: 30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithRetry повторные попытки подключения: всего attempts попыток,
+// пауза начинается с backoff и удваивается после каждой неудачи.
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(o *options) {
+		if attempts > 0 {
+			o.attempts = attempts
+		}
+		if backoff > 0 {
+			o.backoff = backoff
+		}
+	}
+}
+
+// WithMaxBackoff максимальная пауза между попытками подключения (по умолчанию 30 секунд).
+func WithMaxBackoff(d time.Duration) Option {
+	return func(o *options) {
+		o.maxBackoff = d
+	}
+}
+
+// WithMaxWait максимальное общее время ожидания подключения.
+func WithMaxWait(d time.Duration) Option {
+	return func(o *options) {
+		o.maxWait = d
+	}
+}
+
+// WithContext контекст подключения, при его отмене попытки прекращаются.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// WithLogger логирование попыток подключения, изменений состояния и запросов (уровень Debug).
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// connect подключение к БД с повторными попытками.
+func connect(driverName, dsn string, o *options) (*sqlx.DB, error) {
+	if o.lazy {
+		return sqlx.Open(driverName, dsn)
+	}
+
+	ctx := o.ctx
+	parentDeadline, hasParentDeadline := ctx.Deadline()
+	if o.maxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.maxWait)
+		defer cancel()
+	}
+
+	var errs error
+	delay := o.backoff
+	for attempt := 1; ; attempt++ {
+		db, err := connectOnce(ctx, driverName, dsn)
+		if err == nil {
+			if o.logger != nil && attempt > 1 {
+				o.logger.Info("connected to database", "driver", driverName, "attempt", attempt)
+			}
+			return db, nil
+		}
+		errs = multierr.Append(errs, fmt.Errorf("attempt %d: %w", attempt, err))
+
+		if attempt >= o.attempts {
+			return nil, errs
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			// срок WithMaxWait или более ранний срок контекста WithContext
+			if o.maxWait > 0 && (!hasParentDeadline || parentDeadline.After(deadline)) {
+				return nil, multierr.Append(errs, fmt.Errorf("max wait %s exceeded", o.maxWait))
+			}
+			return nil, multierr.Append(errs, fmt.Errorf("context deadline %s before next attempt: %w",
+				deadline.Format(time.RFC3339Nano), context.DeadlineExceeded))
+		}
+		if o.logger != nil {
+			o.logger.Warn("database connection failed",
+				"driver", driverName, "attempt", attempt, "retry_in", delay, "error", err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, multierr.Append(errs, ctx.Err())
+		case <-timer.C:
+		}
+		delay *= 2
+		if o.maxBackoff > 0 && delay > o.maxBackoff {
+			delay = o.maxBackoff
+		}
+	}
+}
+
+func connectOnce(ctx context.Context, driverName, dsn string) (*sqlx.DB, error) {
+	db, err := sqlx.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err = db.PingContext(ctx); err != nil {
+		return nil, multierr.Append(err, db.Close())
+	}
+	return db, nil
+}
@@ -0,0 +1,179 @@
+package dbwrap
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/multierr"
+)
+
+// CopyOptions параметры копирования данных.
+type CopyOptions struct {
+	CreateTable bool // создание таблицы по колонкам запроса, если её нет в БД назначения
+	BatchSize   int  // строк в пакете вставки, по умолчанию 1000
+}
+
+// Copy копирование результата запроса query БД src в таблицу dstTable БД dst, в том числе разных СУБД.
+// Строки читаются по мере вставки пакетами (COPY / bulk copy в postgres и sqlserver, INSERT на несколько строк
+// в остальных БД), значения приводятся к типам колонок dstTable: bit -> boolean, uniqueidentifier -> uuid,
+// decimal -> numeric и т.д. Колонки запроса сопоставляются с колонками таблицы по именам.
+// Время копирования ограничено таймаутом запроса src (QueryTimeout, NoQueryTimeout).
+// src и dst должны использовать разные соединения: чтение и вставка выполняются одновременно.
+//
+// n, err := dbwrap.Copy(ctx, mssqlDB, pgDB, "select * from dbo.people", "people", dbwrap.CopyOptions{CreateTable: true})
+func Copy(ctx context.Context, src, dst *DBSQL, query, dstTable string, opts CopyOptions, args ...any) (n int64, err error) {
+	query, args, err = src.expandIn(src.Dialect().BindType(), query, args)
+	if err != nil {
+		return 0, sqlErr(err, query, args...)
+	}
+
+	ctx, cancel := src.queryContext(ctx)
+	defer cancel()
+	start := time.Now()
+
+	// строки уже могли быть вставлены в dst, поэтому запрос не повторяется
+	err = src.read(ctx, func(q sqlx.QueryerContext) error {
+		n, err = copyRows(ctx, q, src.Dialect().Name(), dst, query, dstTable, opts, args...)
+		return err
+	})
+	if err = src.done(ctx, start, err, query, args...); err != nil {
+		return n, fmt.Errorf("copy %s: %w", dstTable, err)
+	}
+	return n, nil
+}
+
+// copyRows чтение строк запроса и вставка в таблицу dst.
+func copyRows(ctx context.Context, q sqlx.QueryerContext, driver string, dst *DBSQL, query, table string,
+	opts CopyOptions, args ...any) (n int64, err error) {
+	rows, err := q.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		err = multierr.Combine(err, rows.Close())
+	}()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, err
+	}
+	srcCols := make([]*Column, len(types))
+	names := make([]string, len(types))
+	for i, ct := range types {
+		srcCols[i] = queryColumn(ct)
+		names[i] = ct.Name()
+	}
+
+	if opts.CreateTable && !dst.tableExists(ctx, table) {
+		if err = dst.createTable(ctx, driver, table, srcCols); err != nil {
+			return 0, err
+		}
+	}
+	dstCols, err := dst.importColumns(ctx, table, names, nil)
+	if err != nil {
+		return 0, err
+	}
+	srcKinds := make([]columnKind, len(srcCols))
+	for i, c := range srcCols {
+		srcKinds[i] = kindOf(driver, c)
+	}
+
+	im := newImporter(dst, table, dstCols, &ImportOptions{BatchSize: opts.BatchSize})
+	values := make([]any, len(types))
+	ptrs := make([]any, len(types))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	batch := make([][]any, 0, im.opts.BatchSize)
+	for rows.Next() {
+		if err = rows.Scan(ptrs...); err != nil {
+			return n, err
+		}
+		row := make([]any, len(dstCols))
+		for i, c := range dstCols {
+			if row[i], err = copyValue(srcKinds[c.field], c.kind, values[c.field]); err != nil {
+				return n, fmt.Errorf("строка %d, колонка %s: %w", n+int64(len(batch))+1, c.name, err)
+			}
+		}
+		if batch = append(batch, row); len(batch) == cap(batch) {
+			if err = im.load(ctx, batch); err != nil {
+				return n, err
+			}
+			n += int64(len(batch))
+			batch = batch[:0]
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return n, err
+	}
+	if len(batch) > 0 {
+		if err = im.load(ctx, batch); err != nil {
+			return n, err
+		}
+		n += int64(len(batch))
+	}
+	return n, nil
+}
+
+// queryColumn колонка по метаданным результата запроса.
+func queryColumn(ct *sql.ColumnType) *Column {
+	c := &Column{Name: ct.Name(), Nullable: true}
+	c.DataType, c.Length, c.Precision, c.Scale = parseColumnType(ct.DatabaseTypeName())
+	if l, ok := ct.Length(); ok && c.Length == 0 {
+		c.Length = int(l)
+		if l > math.MaxInt32/2 { // text, nvarchar(max)
+			c.Length = -1
+		}
+	}
+	if p, s, ok := ct.DecimalSize(); ok && c.Precision == 0 {
+		c.Precision, c.Scale = int(p), int(s)
+	}
+	if nullable, ok := ct.Nullable(); ok {
+		c.Nullable = nullable
+	}
+	return c
+}
+
+// tableExists проверка существования таблицы запросом без строк.
+func (d *DBSQL) tableExists(ctx context.Context, table string) bool {
+	rows, err := d.ext().QueryxContext(ctx, "SELECT * FROM "+d.Dialect().QuoteIdent(table)+" WHERE 1 = 0")
+	if err != nil {
+		return false
+	}
+	return rows.Close() == nil
+}
+
+// createTable создание таблицы с колонками cols БД драйвера driver.
+func (d *DBSQL) createTable(ctx context.Context, driver, table string, cols []*Column) error {
+	source := &Schema{Driver: driver, Tables: []*Table{{Name: table, Columns: cols}}}
+	for _, query := range CompareSchemas(source, &Schema{Driver: d.Dialect().Name()}).DDL(d.Dialect()) {
+		if _, err := d.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyValue значение колонки вида from в типе колонки вида to.
+func copyValue(from, to columnKind, v any) (any, error) {
+	switch v := v.(type) {
+	case []byte:
+		if to == kindBytes {
+			return v, nil
+		}
+		// uniqueidentifier SQL Server, numeric postgres и mysql в текстовом виде
+		s, _ := exportText(exportColumn{kind: from}, v, &ExportOptions{})
+		return importValue(to, s, &ImportOptions{})
+	case string, bool:
+		return importValue(to, v, &ImportOptions{})
+	case int64:
+		if to == kindBool {
+			return v != 0, nil
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,42 @@
+package dbwrap
+
+import (
+	"testing"
+	"time"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyValue(t *testing.T) {
+	var u mssql.UniqueIdentifier
+	require.NoError(t, u.Scan("6F9619FF-8B86-D011-B42D-00C04FC964FF"))
+	guid, err := u.Value()
+	require.NoError(t, err)
+	opened := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		from, to columnKind
+		in, want any
+	}{
+		{kindUUID, kindUUID, guid, "6F9619FF-8B86-D011-B42D-00C04FC964FF"},
+		{kindBool, kindBool, true, true},
+		{kindInt, kindBool, int64(1), true},
+		{kindBool, kindInt, true, int64(1)},
+		{kindDecimal, kindDecimal, []byte("10.50"), "10.50"},
+		{kindString, kindInt, []byte("42"), int64(42)},
+		{kindTimestamp, kindTimestampTZ, opened, opened},
+		{kindString, kindTimestamp, "2024-01-02 03:04:05", opened},
+		{kindBytes, kindBytes, []byte{1, 2}, []byte{1, 2}},
+		{kindString, kindBool, nil, nil},
+	}
+	for _, tt := range tests {
+		got, err := copyValue(tt.from, tt.to, tt.in)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+
+	_, err = copyValue(kindString, kindInt, "abc")
+	assert.Error(t, err)
+}
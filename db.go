@@ -2,52 +2,94 @@ package dbwrap
 
 import (
 	"fmt"
+	"log/slog"
+	"sync/atomic"
 
 	"errors"
 
 	"github.com/jmoiron/sqlx"
+	"go.uber.org/multierr"
 )
 
 // DBSQL ...
 type DBSQL struct {
-	DBX          *sqlx.DB
-	timeoutQuery int // Second
+	DBX          *sqlx.DB // основная БД (primary), запросы изменения данных и транзакции
+	timeoutQuery int      // Second
+	dialect      Dialect
+	inOptions    InOptions
+	health       *healthMonitor
+	logger       *slog.Logger
+	tx           *sqlx.Tx // транзакция DBSQL из BeginTx
+
+	replicas      []*replica // реплики для читающих запросов
+	replicaPolicy string
+	nextReplica   atomic.Uint64
 }
 
 // ErrBadConfigDB ошибка.
 var ErrBadConfigDB = errors.New("не заполнены параметры подключения к БД")
 
 // NewConnect Создание подключения к БД.
-func NewConnect(cfg *Config) (*DBSQL, error) {
-	if cfg.DriverName != "sqlite3" && cfg.DSN == "" {
-		if cfg.Host == "" || cfg.Database == "" || cfg.User == "" {
-			return nil, ErrBadConfigDB
-		}
+//
+// db, err := dbwrap.NewConnect(cfg, dbwrap.WithRetry(5, time.Second), dbwrap.WithMaxWait(time.Minute))
+func NewConnect(cfg *Config, opts ...Option) (*DBSQL, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.registerTLS(); err != nil {
+		return nil, err
 	}
 	dsn := cfg.GetDatabaseURL()
-	db, err := sqlx.Connect(cfg.DriverName, dsn)
+	o := newOptions(opts)
+	db, err := connect(cfg.DriverName, dsn, o)
 	if err != nil {
 		return nil, fmt.Errorf("sqlx.Connect driver %s dsn %s: %w", cfg.DriverName, dsn, err)
 	}
-	return &DBSQL{DBX: db, timeoutQuery: cfg.TimeoutQuery}, nil
+	cfg.setSQLitePool(db.DB)
+	d := newDBSQL(db, cfg.DriverName, cfg.TimeoutQuery, o)
+	if err = d.openReplicas(cfg, o); err != nil {
+		return nil, multierr.Append(err, d.Close())
+	}
+	return d, nil
 }
 
-// NewConnect Создание подключения к БД.
-func NewConnectDSN(driver, dsn string) (*DBSQL, error) {
+// NewConnectDSN Создание подключения к БД по строке подключения.
+func NewConnectDSN(driver, dsn string, opts ...Option) (*DBSQL, error) {
 	if driver == "" || dsn == "" {
 		return nil, ErrBadConfigDB
 	}
 
-	db, err := sqlx.Connect(driver, dsn)
+	o := newOptions(opts)
+	db, err := connect(driver, dsn, o)
 	if err != nil {
 		return nil, fmt.Errorf("sqlx.Connect driver %s, dsn %s: %w", driver, dsn, err)
 	}
-	return &DBSQL{DBX: db, timeoutQuery: 600}, nil
+	return newDBSQL(db, driver, 600, o), nil
+}
+
+func newDBSQL(db *sqlx.DB, driverName string, timeoutQuery int, o *options) *DBSQL {
+	d := &DBSQL{DBX: db, timeoutQuery: timeoutQuery, dialect: dialectFor(driverName), logger: o.logger}
+	if o.healthInterval > 0 {
+		onChange := o.onHealthChange
+		if o.logger != nil {
+			onChange = func(state HealthState, err error) {
+				o.logger.Info("database state changed", "driver", driverName, "state", state.String(), "error", err)
+				if o.onHealthChange != nil {
+					o.onHealthChange(state, err)
+				}
+			}
+		}
+		d.health = startHealthMonitor(db.DB, o.healthInterval, onChange)
+	}
+	return d
 }
 
 // Close закрытие соединений.
 func (d *DBSQL) Close() error {
-	return d.DBX.Close()
+	if d.health != nil {
+		d.health.stop()
+	}
+	return multierr.Append(d.closeReplicas(), d.DBX.Close())
 }
 
 // SetTimeout установка таймаута для выполнения запроса в секундах.
@@ -59,3 +101,11 @@ func (d *DBSQL) SetTimeoutQuery(timeout uint) {
 func (d *DBSQL) TimeoutQuery() int {
 	return d.timeoutQuery
 }
+
+// Dialect получение диалекта SQL текущего драйвера БД.
+func (d *DBSQL) Dialect() Dialect {
+	if d.dialect != nil {
+		return d.dialect
+	}
+	return dialectFor(d.DBX.DriverName())
+}
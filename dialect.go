@@ -0,0 +1,380 @@
+package dbwrap
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Dialect особенности синтаксиса SQL конкретной СУБД.
+type Dialect interface {
+	// Name наименование драйвера БД.
+	Name() string
+	// BindType тип параметров запроса (sqlx.QUESTION, sqlx.DOLLAR, sqlx.AT ...).
+	BindType() int
+	// Placeholder параметр запроса с порядковым номером n (начиная с 1).
+	Placeholder(n int) string
+	// QuoteIdent экранирование идентификатора, имена вида schema.table экранируются по частям.
+	QuoteIdent(name string) string
+	// LimitOffset ограничение выборки, добавляется в конец запроса после ORDER BY.
+	LimitOffset(limit, offset int) string
+	// BoolLiteral логическая константа.
+	BoolLiteral(v bool) string
+	// CurrentTimestamp функция получения текущего времени.
+	CurrentTimestamp() string
+	// Savepoint создание точки сохранения транзакции.
+	Savepoint(name string) string
+	// RollbackToSavepoint откат к точке сохранения.
+	RollbackToSavepoint(name string) string
+	// ReleaseSavepoint освобождение точки сохранения, пустая строка если не поддерживается.
+	ReleaseSavepoint(name string) string
+	// MaxParams максимальное количество параметров в одном запросе.
+	MaxParams() int
+	// Upsert запрос вставки или обновления строки по ключевым колонкам.
+	// Значения передаются именованными параметрами :column для Named* методов.
+	// Без ключевых колонок строка только вставляется, дубликаты уникальных индексов пропускаются.
+	Upsert(table string, columns, keys []string) string
+}
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = map[string]Dialect{
+		"sqlserver": sqlserverDialect{},
+		"postgres":  postgresDialect{},
+		"mysql":     mysqlDialect{},
+		"sqlite3":   sqlite3Dialect{},
+	}
+)
+
+// RegisterDialect регистрация диалекта для драйвера БД.
+// Повторная регистрация заменяет ранее установленный диалект.
+func RegisterDialect(driverName string, d Dialect) {
+	if d == nil {
+		panic("dbwrap: RegisterDialect dialect is nil")
+	}
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	dialects[driverName] = d
+}
+
+// GetDialect получение зарегистрированного диалекта драйвера БД.
+func GetDialect(driverName string) (Dialect, bool) {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+	d, ok := dialects[driverName]
+	return d, ok
+}
+
+// dialectFor диалект драйвера, для незарегистрированных драйверов используется ANSI SQL.
+func dialectFor(driverName string) Dialect {
+	if d, ok := GetDialect(driverName); ok {
+		return d
+	}
+	return ansiDialect{name: driverName, bindType: sqlx.BindType(driverName)}
+}
+
+// ansiDialect синтаксис по стандарту SQL, основа для остальных диалектов.
+type ansiDialect struct {
+	name     string
+	bindType int
+}
+
+func (d ansiDialect) Name() string { return d.name }
+
+func (d ansiDialect) BindType() int { return d.bindType }
+
+func (d ansiDialect) Placeholder(n int) string {
+	switch d.bindType {
+	case sqlx.DOLLAR:
+		return fmt.Sprintf("$%d", n)
+	case sqlx.AT:
+		return fmt.Sprintf("@p%d", n)
+	case sqlx.NAMED:
+		return fmt.Sprintf(":arg%d", n)
+	default:
+		return "?"
+	}
+}
+
+func (d ansiDialect) QuoteIdent(name string) string {
+	return quoteIdent(name, `"`, `"`)
+}
+
+func (d ansiDialect) LimitOffset(limit, offset int) string {
+	if offset > 0 {
+		return fmt.Sprintf("OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", offset, limit)
+	}
+	return fmt.Sprintf("FETCH FIRST %d ROWS ONLY", limit)
+}
+
+func (d ansiDialect) BoolLiteral(v bool) string {
+	if v {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+func (d ansiDialect) CurrentTimestamp() string { return "CURRENT_TIMESTAMP" }
+
+func (d ansiDialect) Savepoint(name string) string { return "SAVEPOINT " + name }
+
+func (d ansiDialect) RollbackToSavepoint(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + name
+}
+
+func (d ansiDialect) ReleaseSavepoint(name string) string { return "RELEASE SAVEPOINT " + name }
+
+func (d ansiDialect) MaxParams() int { return 999 }
+
+func (d ansiDialect) Upsert(table string, columns, keys []string) string {
+	return onConflictUpsert(d, table, columns, keys, "excluded")
+}
+
+// onConflictUpsert INSERT ... ON CONFLICT (postgres, sqlite3).
+func onConflictUpsert(q Dialect, table string, columns, keys []string, excluded string) string {
+	var sb strings.Builder
+	writeInsert(&sb, q, table, columns)
+	if len(keys) == 0 {
+		sb.WriteString(" ON CONFLICT DO NOTHING")
+		return sb.String()
+	}
+	sb.WriteString(" ON CONFLICT (")
+	sb.WriteString(quoteList(q, keys))
+	sb.WriteString(")")
+	updates := nonKeyColumns(columns, keys)
+	if len(updates) == 0 {
+		sb.WriteString(" DO NOTHING")
+		return sb.String()
+	}
+	sb.WriteString(" DO UPDATE SET ")
+	for i, c := range updates {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "%s = %s.%s", q.QuoteIdent(c), excluded, q.QuoteIdent(c))
+	}
+	return sb.String()
+}
+
+// sqlserverDialect Microsoft SQL Server.
+type sqlserverDialect struct{ ansiDialect }
+
+func (sqlserverDialect) Name() string  { return "sqlserver" }
+func (sqlserverDialect) BindType() int { return sqlx.AT }
+
+func (sqlserverDialect) Placeholder(n int) string { return fmt.Sprintf("@p%d", n) }
+
+func (sqlserverDialect) QuoteIdent(name string) string {
+	return quoteIdent(name, "[", "]")
+}
+
+// LimitOffset OFFSET/FETCH в SQL Server допускается только вместе с ORDER BY.
+func (sqlserverDialect) LimitOffset(limit, offset int) string {
+	return fmt.Sprintf("OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", offset, limit)
+}
+
+func (sqlserverDialect) BoolLiteral(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
+}
+
+func (sqlserverDialect) CurrentTimestamp() string { return "SYSDATETIME()" }
+
+func (sqlserverDialect) Savepoint(name string) string { return "SAVE TRANSACTION " + name }
+
+func (sqlserverDialect) RollbackToSavepoint(name string) string {
+	return "ROLLBACK TRANSACTION " + name
+}
+
+// ReleaseSavepoint в SQL Server точки сохранения не освобождаются.
+func (sqlserverDialect) ReleaseSavepoint(string) string { return "" }
+
+// MaxParams SQL Server допускает до 2100 параметров в запросе.
+func (sqlserverDialect) MaxParams() int { return 2100 }
+
+// Upsert без ключевых колонок INSERT, ошибки дубликата уникального индекса (2601, 2627) пропускаются.
+func (d sqlserverDialect) Upsert(table string, columns, keys []string) string {
+	var sb strings.Builder
+	if len(keys) == 0 {
+		sb.WriteString("BEGIN TRY ")
+		writeInsert(&sb, d, table, columns)
+		sb.WriteString(" END TRY BEGIN CATCH IF ERROR_NUMBER() NOT IN (2601, 2627) THROW; END CATCH")
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "MERGE INTO %s AS target USING (SELECT ", d.QuoteIdent(table))
+	for i, c := range columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, ":%s AS %s", c, d.QuoteIdent(c))
+	}
+	sb.WriteString(") AS source ON ")
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteString(" AND ")
+		}
+		fmt.Fprintf(&sb, "target.%s = source.%s", d.QuoteIdent(k), d.QuoteIdent(k))
+	}
+	if updates := nonKeyColumns(columns, keys); len(updates) > 0 {
+		sb.WriteString(" WHEN MATCHED THEN UPDATE SET ")
+		for i, c := range updates {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			fmt.Fprintf(&sb, "%s = source.%s", d.QuoteIdent(c), d.QuoteIdent(c))
+		}
+	}
+	fmt.Fprintf(&sb, " WHEN NOT MATCHED THEN INSERT (%s) VALUES (", quoteList(d, columns))
+	for i, c := range columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("source." + d.QuoteIdent(c))
+	}
+	sb.WriteString(");")
+	return sb.String()
+}
+
+// postgresDialect PostgreSQL.
+type postgresDialect struct{ ansiDialect }
+
+func (postgresDialect) Name() string  { return "postgres" }
+func (postgresDialect) BindType() int { return sqlx.DOLLAR }
+
+func (postgresDialect) Placeholder(n int) string { return fmt.Sprintf("$%d", n) }
+
+func (postgresDialect) LimitOffset(limit, offset int) string {
+	return limitOffset(limit, offset)
+}
+
+// MaxParams протокол PostgreSQL ограничивает количество параметров 65535.
+func (postgresDialect) MaxParams() int { return 65535 }
+
+func (d postgresDialect) Upsert(table string, columns, keys []string) string {
+	return onConflictUpsert(d, table, columns, keys, "EXCLUDED")
+}
+
+// mysqlDialect MySQL / MariaDB.
+type mysqlDialect struct{ ansiDialect }
+
+func (mysqlDialect) Name() string  { return "mysql" }
+func (mysqlDialect) BindType() int { return sqlx.QUESTION }
+
+func (mysqlDialect) Placeholder(int) string { return "?" }
+
+func (mysqlDialect) QuoteIdent(name string) string {
+	return quoteIdent(name, "`", "`")
+}
+
+func (mysqlDialect) LimitOffset(limit, offset int) string {
+	return limitOffset(limit, offset)
+}
+
+// MaxParams протокол MySQL ограничивает количество параметров 65535.
+func (mysqlDialect) MaxParams() int { return 65535 }
+
+func (d mysqlDialect) Upsert(table string, columns, keys []string) string {
+	var sb strings.Builder
+	writeInsert(&sb, d, table, columns)
+	if len(keys) == 0 {
+		return "INSERT IGNORE" + strings.TrimPrefix(sb.String(), "INSERT")
+	}
+	sb.WriteString(" ON DUPLICATE KEY UPDATE ")
+	updates := nonKeyColumns(columns, keys)
+	if len(updates) == 0 {
+		// обновление ключа самим собой, чтобы не получить ошибку дубликата
+		updates = keys[:1]
+	}
+	for i, c := range updates {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "%s = VALUES(%s)", d.QuoteIdent(c), d.QuoteIdent(c))
+	}
+	return sb.String()
+}
+
+// sqlite3Dialect SQLite.
+type sqlite3Dialect struct{ ansiDialect }
+
+func (sqlite3Dialect) Name() string  { return "sqlite3" }
+func (sqlite3Dialect) BindType() int { return sqlx.QUESTION }
+
+func (sqlite3Dialect) Placeholder(int) string { return "?" }
+
+func (sqlite3Dialect) LimitOffset(limit, offset int) string {
+	return limitOffset(limit, offset)
+}
+
+func (sqlite3Dialect) BoolLiteral(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
+}
+
+// MaxParams SQLITE_MAX_VARIABLE_NUMBER для SQLite 3.32 и новее.
+func (sqlite3Dialect) MaxParams() int { return 32766 }
+
+func (d sqlite3Dialect) Upsert(table string, columns, keys []string) string {
+	return onConflictUpsert(d, table, columns, keys, "excluded")
+}
+
+func limitOffset(limit, offset int) string {
+	if offset > 0 {
+		return fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset)
+	}
+	return fmt.Sprintf("LIMIT %d", limit)
+}
+
+// quoteIdent экранирование идентификатора с учётом составных имён.
+func quoteIdent(name, open, closing string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		if p == "*" {
+			continue
+		}
+		parts[i] = open + strings.ReplaceAll(p, closing, closing+closing) + closing
+	}
+	return strings.Join(parts, ".")
+}
+
+func quoteList(d Dialect, names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = d.QuoteIdent(n)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func writeInsert(sb *strings.Builder, d Dialect, table string, columns []string) {
+	fmt.Fprintf(sb, "INSERT INTO %s (%s) VALUES (", d.QuoteIdent(table), quoteList(d, columns))
+	for i, c := range columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(":" + c)
+	}
+	sb.WriteString(")")
+}
+
+func nonKeyColumns(columns, keys []string) []string {
+	var res []string
+	for _, c := range columns {
+		isKey := false
+		for _, k := range keys {
+			if strings.EqualFold(c, k) {
+				isKey = true
+				break
+			}
+		}
+		if !isKey {
+			res = append(res, c)
+		}
+	}
+	return res
+}
@@ -0,0 +1,84 @@
+package dbwrap
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialectSyntax(t *testing.T) {
+
+	var tests = []struct {
+		driver      string
+		placeholder string
+		quote       string
+		limit       string
+		boolTrue    string
+	}{
+		{"sqlserver", "@p2", "[dbo].[people]", "OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY", "1"},
+		{"postgres", "$2", `"dbo"."people"`, "LIMIT 10 OFFSET 20", "TRUE"},
+		{"mysql", "?", "`dbo`.`people`", "LIMIT 10 OFFSET 20", "TRUE"},
+		{"sqlite3", "?", `"dbo"."people"`, "LIMIT 10 OFFSET 20", "1"},
+	}
+	for _, test := range tests {
+		d, ok := GetDialect(test.driver)
+		assert.True(t, ok, test.driver)
+		assert.Equal(t, test.driver, d.Name())
+		assert.Equal(t, test.placeholder, d.Placeholder(2), test.driver)
+		assert.Equal(t, test.quote, d.QuoteIdent("dbo.people"), test.driver)
+		assert.Equal(t, test.limit, d.LimitOffset(10, 20), test.driver)
+		assert.Equal(t, test.boolTrue, d.BoolLiteral(true), test.driver)
+	}
+}
+
+func TestDialectUpsert(t *testing.T) {
+
+	var tests = []struct {
+		driver string
+		want   string
+	}{
+		{"postgres", `INSERT INTO "people" ("id", "name") VALUES (:id, :name) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`},
+		{"sqlite3", `INSERT INTO "people" ("id", "name") VALUES (:id, :name) ON CONFLICT ("id") DO UPDATE SET "name" = excluded."name"`},
+		{"mysql", "INSERT INTO `people` (`id`, `name`) VALUES (:id, :name) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)"},
+		{"sqlserver", "MERGE INTO [people] AS target USING (SELECT :id AS [id], :name AS [name]) AS source ON target.[id] = source.[id]" +
+			" WHEN MATCHED THEN UPDATE SET [name] = source.[name] WHEN NOT MATCHED THEN INSERT ([id], [name]) VALUES (source.[id], source.[name]);"},
+	}
+	for _, test := range tests {
+		d, _ := GetDialect(test.driver)
+		assert.Equal(t, test.want, d.Upsert("people", []string{"id", "name"}, []string{"id"}))
+	}
+
+	// без ключевых колонок
+	tests = []struct {
+		driver string
+		want   string
+	}{
+		{"postgres", `INSERT INTO "people" ("id", "name") VALUES (:id, :name) ON CONFLICT DO NOTHING`},
+		{"sqlite3", `INSERT INTO "people" ("id", "name") VALUES (:id, :name) ON CONFLICT DO NOTHING`},
+		{"mysql", "INSERT IGNORE INTO `people` (`id`, `name`) VALUES (:id, :name)"},
+		{"sqlserver", "BEGIN TRY INSERT INTO [people] ([id], [name]) VALUES (:id, :name) " +
+			"END TRY BEGIN CATCH IF ERROR_NUMBER() NOT IN (2601, 2627) THROW; END CATCH"},
+	}
+	for _, test := range tests {
+		d, _ := GetDialect(test.driver)
+		assert.NotPanics(t, func() { d.Upsert("people", nil, nil) })
+		assert.Equal(t, test.want, d.Upsert("people", []string{"id", "name"}, nil))
+	}
+}
+
+func TestRegisterDialect(t *testing.T) {
+	_, ok := GetDialect("custom")
+	assert.False(t, ok)
+	assert.Equal(t, sqlx.DOLLAR, dialectFor("pgx").BindType())
+
+	RegisterDialect("custom", postgresDialect{})
+	defer func() {
+		dialectsMu.Lock()
+		delete(dialects, "custom")
+		dialectsMu.Unlock()
+	}()
+	d, ok := GetDialect("custom")
+	assert.True(t, ok)
+	assert.Equal(t, "$1", d.Placeholder(1))
+}
@@ -0,0 +1,309 @@
+package dbwrap
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+	mssql "github.com/microsoft/go-mssqldb"
+	"go.uber.org/multierr"
+)
+
+// ErrExportFormat ошибка.
+var ErrExportFormat = errors.New("неизвестный формат выгрузки")
+
+// ExportFormat формат выгрузки результата запроса.
+type ExportFormat string
+
+// Форматы выгрузки.
+const (
+	ExportCSV   ExportFormat = "csv"   // CSV с заголовком
+	ExportJSONL ExportFormat = "jsonl" // JSON Lines, объект на строку
+	ExportXLSX  ExportFormat = "xlsx"  // книга Excel с одним листом
+)
+
+// ExportOptions параметры выгрузки.
+type ExportOptions struct {
+	Format           ExportFormat
+	Null             string // представление NULL в CSV и XLSX, по умолчанию пустое (в XLSX пустая ячейка)
+	TimeFormat       string // формат даты и времени CSV и JSONL, по умолчанию time.RFC3339Nano
+	DateFormat       string // формат колонок типа date, по умолчанию 2006-01-02
+	DecimalSeparator string // разделитель дробной части чисел в CSV, "," для Excel с русскими настройками
+	DecimalAsString  bool   // numeric/decimal строками в JSONL и XLSX без потери точности, по умолчанию числами
+	Comma            rune   // разделитель полей CSV, по умолчанию ','
+	SheetName        string // имя листа XLSX, по умолчанию Sheet1
+}
+
+// exportColumn колонка результата запроса.
+type exportColumn struct {
+	name string
+	kind columnKind
+}
+
+func (c exportColumn) numeric() bool {
+	switch c.kind {
+	case kindInt, kindBigInt, kindFloat, kindDecimal:
+		return true
+	}
+	return false
+}
+
+// rowWriter запись строк результата в формате выгрузки.
+type rowWriter interface {
+	header(cols []exportColumn) error
+	row(values []any) error
+	close() error
+}
+
+// ExportContext выгрузка результата запроса в w по строкам, без чтения всего результата в память.
+// Колонки выводятся в порядке запроса. Возвращает количество выгруженных строк.
+//
+// n, err := db.ExportContext(ctx, file, "select * from sales", dbwrap.ExportOptions{Format: dbwrap.ExportCSV, Comma: ';'})
+func (d *DBSQL) ExportContext(ctx context.Context, w io.Writer, query string, opts ExportOptions, args ...any) (n int64, err error) {
+	rw, err := newRowWriter(w, opts)
+	if err != nil {
+		return 0, err
+	}
+	query, args, err = d.expandIn(d.Dialect().BindType(), query, args)
+	if err != nil {
+		return 0, sqlErr(err, query, args...)
+	}
+
+	// ограничим время выполнения запроса по умолчанию или QueryTimeout
+	ctx, cancel := d.queryContext(ctx)
+	defer cancel()
+	start := time.Now()
+
+	// строки уже могли быть записаны в w, поэтому запрос не повторяется
+	err = d.read(ctx, func(q sqlx.QueryerContext) error {
+		n, err = exportRows(ctx, q, d.Dialect().Name(), rw, maxRowsFrom(ctx), query, args...)
+		return err
+	})
+	if err = d.done(ctx, start, err, query, args...); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// NamedExportContext выгрузка результата запроса с именованными параметрами.
+func (d *DBSQL) NamedExportContext(ctx context.Context, w io.Writer, query string, opts ExportOptions, arg any) (int64, error) {
+	nq, args, err := d.bindNamed(query, arg)
+	if err != nil {
+		return 0, err
+	}
+
+	return d.ExportContext(ctx, w, nq, opts, args...)
+}
+
+func newRowWriter(w io.Writer, opts ExportOptions) (rowWriter, error) {
+	if opts.TimeFormat == "" {
+		opts.TimeFormat = time.RFC3339Nano
+	}
+	if opts.DateFormat == "" {
+		opts.DateFormat = time.DateOnly
+	}
+	switch opts.Format {
+	case ExportCSV:
+		cw := csv.NewWriter(w)
+		if opts.Comma != 0 {
+			cw.Comma = opts.Comma
+		}
+		return &csvWriter{w: cw, opts: opts}, nil
+	case ExportJSONL:
+		return &jsonlWriter{w: bufio.NewWriter(w), opts: opts}, nil
+	case ExportXLSX:
+		return newXLSXWriter(w, opts), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrExportFormat, opts.Format)
+}
+
+// exportRows чтение строк запроса и запись в rw, при maxRows > 0 не более maxRows строк.
+func exportRows(ctx context.Context, q sqlx.QueryerContext, driver string, rw rowWriter, maxRows int, query string, args ...any) (n int64, err error) {
+	rows, err := q.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		err = multierr.Combine(err, rows.Close())
+	}()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, err
+	}
+	cols := make([]exportColumn, len(types))
+	for i, ct := range types {
+		c := &Column{}
+		c.DataType, c.Length, c.Precision, c.Scale = parseColumnType(ct.DatabaseTypeName())
+		cols[i] = exportColumn{name: ct.Name(), kind: kindOf(driver, c)}
+	}
+	if err = rw.header(cols); err != nil {
+		return 0, err
+	}
+
+	values := make([]any, len(cols))
+	ptrs := make([]any, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if maxRows > 0 && n >= int64(maxRows) {
+			return n, fmt.Errorf("%w: %d", ErrMaxRows, maxRows)
+		}
+		if err = rows.Scan(ptrs...); err != nil {
+			return n, err
+		}
+		if err = rw.row(values); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err = rows.Err(); err != nil {
+		return n, err
+	}
+	return n, rw.close()
+}
+
+// exportText значение колонки строкой, null - значение NULL.
+func exportText(c exportColumn, v any, opts *ExportOptions) (s string, null bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", true
+	case time.Time:
+		if c.kind == kindDate {
+			return v.Format(opts.DateFormat), false
+		}
+		return v.Format(opts.TimeFormat), false
+	case []byte:
+		// uniqueidentifier SQL Server в двоичном виде
+		if c.kind == kindUUID && len(v) == 16 {
+			var u mssql.UniqueIdentifier
+			if err := u.Scan(v); err == nil {
+				return u.String(), false
+			}
+		}
+		return string(v), false
+	case string:
+		return v, false
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), false
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), false
+	}
+	return fmt.Sprint(v), false
+}
+
+// csvWriter выгрузка в CSV.
+type csvWriter struct {
+	w      *csv.Writer
+	opts   ExportOptions
+	cols   []exportColumn
+	record []string
+}
+
+func (cw *csvWriter) header(cols []exportColumn) error {
+	cw.cols = cols
+	cw.record = make([]string, len(cols))
+	for i, c := range cols {
+		cw.record[i] = c.name
+	}
+	return cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) row(values []any) error {
+	for i, v := range values {
+		s, null := exportText(cw.cols[i], v, &cw.opts)
+		switch {
+		case null:
+			s = cw.opts.Null
+		case cw.opts.DecimalSeparator != "" && cw.cols[i].numeric():
+			s = strings.Replace(s, ".", cw.opts.DecimalSeparator, 1)
+		}
+		cw.record[i] = s
+	}
+	return cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// jsonlWriter выгрузка в JSON Lines, ключи объекта в порядке колонок запроса.
+type jsonlWriter struct {
+	w    *bufio.Writer
+	opts ExportOptions
+	cols []exportColumn
+	keys [][]byte
+}
+
+func (jw *jsonlWriter) header(cols []exportColumn) error {
+	jw.cols = cols
+	jw.keys = make([][]byte, len(cols))
+	for i, c := range cols {
+		key, err := json.Marshal(c.name)
+		if err != nil {
+			return err
+		}
+		jw.keys[i] = append(key, ':')
+	}
+	return nil
+}
+
+func (jw *jsonlWriter) row(values []any) error {
+	jw.w.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			jw.w.WriteByte(',')
+		}
+		jw.w.Write(jw.keys[i])
+		val, err := jw.value(jw.cols[i], v)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", jw.cols[i].name, err)
+		}
+		jw.w.Write(val)
+	}
+	_, err := jw.w.WriteString("}\n")
+	return err
+}
+
+func (jw *jsonlWriter) value(c exportColumn, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return []byte("null"), nil
+	case bool, int64, int32, int:
+		return json.Marshal(v)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return []byte("null"), nil
+		}
+		if c.kind == kindDecimal && jw.opts.DecimalAsString {
+			return json.Marshal(strconv.FormatFloat(v, 'f', -1, 64))
+		}
+		return json.Marshal(v)
+	case []byte:
+		if c.kind != kindUUID && !utf8.Valid(v) {
+			return json.Marshal(v) // base64
+		}
+	}
+	s, _ := exportText(c, v, &jw.opts)
+	// числа из драйвера в текстовом виде (numeric postgres, decimal mysql)
+	if c.numeric() && !(c.kind == kindDecimal && jw.opts.DecimalAsString) && json.Valid([]byte(s)) {
+		return []byte(s), nil
+	}
+	return json.Marshal(s)
+}
+
+func (jw *jsonlWriter) close() error {
+	return jw.w.Flush()
+}
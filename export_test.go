@@ -0,0 +1,22 @@
+package dbwrap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXLSXHelpers(t *testing.T) {
+	assert.Equal(t, "A", xlsxColumn(0))
+	assert.Equal(t, "Z", xlsxColumn(25))
+	assert.Equal(t, "AA", xlsxColumn(26))
+	assert.Equal(t, "AZ", xlsxColumn(51))
+	assert.Equal(t, "BA", xlsxColumn(52))
+
+	assert.Equal(t, 45292.0, excelTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 45292.5, excelTime(time.Date(2024, 1, 1, 12, 0, 0, 0, time.FixedZone("MSK", 3*3600))))
+
+	assert.Equal(t, "Sheet1", xlsxSheetName(""))
+	assert.Equal(t, "Продажи_2024", xlsxSheetName("Продажи/2024"))
+}
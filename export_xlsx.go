@@ -0,0 +1,227 @@
+package dbwrap
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// стили ячеек xl/styles.xml
+const (
+	xlsxStyleDate     = 1
+	xlsxStyleDateTime = 2
+	xlsxStyleHeader   = 3
+)
+
+var xlsxStatic = []struct{ name, body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<numFmts count="2"><numFmt numFmtId="164" formatCode="yyyy-mm-dd"/><numFmt numFmtId="165" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts>` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="4"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+		`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+		`</styleSheet>`},
+}
+
+// xlsxWriter выгрузка в книгу Excel (Office Open XML) с одним листом.
+// Строки записываются в лист по мере чтения, ячейки строк - встроенными строками (inlineStr).
+type xlsxWriter struct {
+	zw     *zip.Writer
+	sheet  *bufio.Writer
+	opts   ExportOptions
+	cols   []exportColumn
+	refs   []string // буквы колонок A, B ... AA
+	rowNum int
+}
+
+func newXLSXWriter(w io.Writer, opts ExportOptions) *xlsxWriter {
+	return &xlsxWriter{zw: zip.NewWriter(w), opts: opts}
+}
+
+func (xw *xlsxWriter) header(cols []exportColumn) error {
+	for _, f := range xlsxStatic {
+		if err := xw.writeFile(f.name, f.body); err != nil {
+			return err
+		}
+	}
+	workbook := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + xmlEscape(xlsxSheetName(xw.opts.SheetName)) + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	if err := xw.writeFile("xl/workbook.xml", workbook); err != nil {
+		return err
+	}
+
+	f, err := xw.zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	xw.sheet = bufio.NewWriter(f)
+	xw.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	xw.cols = cols
+	xw.refs = make([]string, len(cols))
+	for i := range cols {
+		xw.refs[i] = xlsxColumn(i)
+	}
+	xw.startRow()
+	for i, c := range cols {
+		xw.stringCell(i, c.name, xlsxStyleHeader)
+	}
+	_, err = xw.sheet.WriteString("</row>")
+	return err
+}
+
+func (xw *xlsxWriter) writeFile(name, body string) error {
+	f, err := xw.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(f, body)
+	return err
+}
+
+func (xw *xlsxWriter) startRow() {
+	xw.rowNum++
+	xw.sheet.WriteString(`<row r="` + strconv.Itoa(xw.rowNum) + `">`)
+}
+
+func (xw *xlsxWriter) row(values []any) error {
+	xw.startRow()
+	for i, v := range values {
+		c := xw.cols[i]
+		switch v := v.(type) {
+		case nil:
+			if xw.opts.Null != "" {
+				xw.stringCell(i, xw.opts.Null, 0)
+			}
+			continue
+		case bool:
+			b := "0"
+			if v {
+				b = "1"
+			}
+			xw.cell(i, `t="b"`, b)
+			continue
+		case time.Time:
+			style := xlsxStyleDateTime
+			if c.kind == kindDate {
+				style = xlsxStyleDate
+			}
+			xw.cell(i, `s="`+strconv.Itoa(style)+`"`, strconv.FormatFloat(excelTime(v), 'f', -1, 64))
+			continue
+		case int64, int32, int, float32, float64:
+			if !(c.kind == kindDecimal && xw.opts.DecimalAsString) {
+				s, _ := exportText(c, v, &xw.opts)
+				xw.cell(i, "", s)
+				continue
+			}
+		}
+		s, _ := exportText(c, v, &xw.opts)
+		// числа из драйвера в текстовом виде
+		if c.numeric() && !(c.kind == kindDecimal && xw.opts.DecimalAsString) {
+			if _, err := strconv.ParseFloat(s, 64); err == nil {
+				xw.cell(i, "", s)
+				continue
+			}
+		}
+		xw.stringCell(i, s, 0)
+	}
+	_, err := xw.sheet.WriteString("</row>")
+	return err
+}
+
+func (xw *xlsxWriter) cell(i int, attrs, value string) {
+	xw.sheet.WriteString(`<c r="` + xw.refs[i] + strconv.Itoa(xw.rowNum) + `"`)
+	if attrs != "" {
+		xw.sheet.WriteString(" " + attrs)
+	}
+	xw.sheet.WriteString("><v>" + value + "</v></c>")
+}
+
+func (xw *xlsxWriter) stringCell(i int, s string, style int) {
+	xw.sheet.WriteString(`<c r="` + xw.refs[i] + strconv.Itoa(xw.rowNum) + `" t="inlineStr"`)
+	if style != 0 {
+		xw.sheet.WriteString(` s="` + strconv.Itoa(style) + `"`)
+	}
+	xw.sheet.WriteString(`><is><t xml:space="preserve">` + xmlEscape(s) + `</t></is></c>`)
+}
+
+func (xw *xlsxWriter) close() error {
+	if xw.sheet == nil {
+		return xw.zw.Close()
+	}
+	xw.sheet.WriteString("</sheetData></worksheet>")
+	if err := xw.sheet.Flush(); err != nil {
+		return err
+	}
+	return xw.zw.Close()
+}
+
+// excelEpoch начало отсчёта дат Excel (с учётом ошибки 1900 года для дат после 01.03.1900).
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// excelTime дата и время Excel: дни от excelEpoch, время - дробная часть. Используется время часового пояса значения.
+func excelTime(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return float64(wall.Unix()-excelEpoch.Unix())/86400 + float64(wall.Nanosecond())/float64(24*time.Hour)
+}
+
+// xlsxColumn буквенное обозначение колонки с индексом i: 0 - A, 26 - AA.
+func xlsxColumn(i int) string {
+	var name []byte
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = append([]byte{byte('A' + (i-1)%26)}, name...)
+	}
+	return string(name)
+}
+
+// xlsxSheetName имя листа без недопустимых символов, не длиннее 31 символа.
+func xlsxSheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if r := []rune(name); len(r) > 31 {
+		name = string(r[:31])
+	}
+	if name == "" {
+		return "Sheet1"
+	}
+	return name
+}
+
+func xmlEscape(s string) string {
+	var sb strings.Builder
+	_ = xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
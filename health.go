@@ -0,0 +1,180 @@
+package dbwrap
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// HealthState состояние подключения к БД.
+type HealthState int32
+
+// Состояния подключения к БД.
+const (
+	StateUnknown HealthState = iota // проверка ещё не выполнялась
+	StateUp                         // БД доступна
+	StateDown                       // БД недоступна
+)
+
+// String наименование состояния.
+func (s HealthState) String() string {
+	switch s {
+	case StateUp:
+		return "up"
+	case StateDown:
+		return "down"
+	default:
+		return "unknown"
+	}
+}
+
+// HealthFunc обработчик изменения состояния подключения, err - ошибка проверки для StateDown.
+type HealthFunc func(state HealthState, err error)
+
+// WithLazy создание DBSQL без подключения к БД, соединения открываются при первом запросе.
+func WithLazy() Option {
+	return func(o *options) {
+		o.lazy = true
+	}
+}
+
+// WithHealthCheck фоновая проверка доступности БД с периодом interval.
+// onChange вызывается при каждом изменении состояния, может быть nil.
+func WithHealthCheck(interval time.Duration, onChange HealthFunc) Option {
+	return func(o *options) {
+		o.healthInterval = interval
+		o.onHealthChange = onChange
+	}
+}
+
+// healthMonitor фоновая проверка доступности БД.
+type healthMonitor struct {
+	mu       sync.RWMutex
+	state    HealthState
+	latency  time.Duration // время ответа последней успешной проверки
+	onChange HealthFunc
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func startHealthMonitor(db *sql.DB, interval time.Duration, onChange HealthFunc) *healthMonitor {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &healthMonitor{onChange: onChange, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			m.check(ctx, db, interval)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return m
+}
+
+func (m *healthMonitor) check(ctx context.Context, db *sql.DB, timeout time.Duration) {
+	pingCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	err := db.PingContext(pingCtx)
+	if ctx.Err() != nil {
+		return
+	}
+	state := StateUp
+	if err != nil {
+		state = StateDown
+	}
+	m.set(state, time.Since(start), err)
+}
+
+func (m *healthMonitor) set(state HealthState, latency time.Duration, err error) {
+	m.mu.Lock()
+	changed := m.state != state
+	m.state = state
+	if state == StateUp {
+		m.latency = latency
+	}
+	m.mu.Unlock()
+	if changed && m.onChange != nil {
+		m.onChange(state, err)
+	}
+}
+
+// markDown перевод в StateDown до следующей успешной проверки.
+func (m *healthMonitor) markDown(err error) {
+	m.set(StateDown, 0, err)
+}
+
+func (m *healthMonitor) State() HealthState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
+
+// Latency время ответа последней успешной проверки.
+func (m *healthMonitor) Latency() time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.latency
+}
+
+func (m *healthMonitor) stop() {
+	m.cancel()
+	<-m.done
+}
+
+// State состояние подключения по данным фоновой проверки (WithHealthCheck).
+func (d *DBSQL) State() HealthState {
+	if d.health == nil {
+		return StateUnknown
+	}
+	return d.health.State()
+}
+
+// isBadConn ошибка разорванного соединения, после которой запрос можно повторить.
+func isBadConn(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// retryRead однократный повтор читающего запроса после разрыва соединения.
+// dest возвращается к исходной длине, если это слайс, заполненный первой попыткой.
+func retryRead(ctx context.Context, dest any, read func() error) error {
+	var (
+		slice   reflect.Value
+		origLen int
+	)
+	if v := reflect.ValueOf(dest); v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Slice {
+		slice = v.Elem()
+		origLen = slice.Len()
+	}
+	err := read()
+	if !isBadConn(err) || ctx.Err() != nil {
+		return err
+	}
+	if slice.IsValid() {
+		slice.SetLen(origLen)
+	}
+	return read()
+}
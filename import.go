@@ -0,0 +1,632 @@
+package dbwrap
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	mssql "github.com/microsoft/go-mssqldb"
+	"go.uber.org/multierr"
+)
+
+// ошибки загрузки
+var (
+	ErrImportFormat = errors.New("неизвестный формат загрузки")
+	ErrImportColumn = errors.New("колонка не найдена")
+	ErrImportErrors = errors.New("превышено количество ошибок загрузки")
+)
+
+// ImportOptions параметры загрузки.
+type ImportOptions struct {
+	Format           ExportFormat      // ExportCSV (с заголовком) или ExportJSONL
+	Columns          map[string]string // поле файла -> колонка таблицы, загружаются только указанные поля; по умолчанию по именам полей
+	Null             string            // значение NULL в CSV, по умолчанию пустая строка
+	TimeFormat       string            // формат даты и времени, по умолчанию RFC 3339 или 2006-01-02 15:04:05
+	DateFormat       string            // формат колонок типа date, по умолчанию 2006-01-02
+	DecimalSeparator string            // разделитель дробной части чисел, "," для файлов Excel с русскими настройками
+	Comma            rune              // разделитель полей CSV, по умолчанию ','
+	BatchSize        int               // строк в пакете загрузки, по умолчанию 1000
+	MaxErrors        int               // прерывание загрузки после MaxErrors ошибок строк, 0 - без ограничения
+}
+
+// ImportResult результат загрузки.
+type ImportResult struct {
+	Rows   int64       // загружено строк
+	Errors []*RowError // строки, которые не удалось загрузить
+}
+
+// RowError ошибка загрузки строки файла.
+type RowError struct {
+	Line   int    // номер строки файла, начиная с 1
+	Column string // колонка таблицы, пусто - ошибка всей строки
+	Err    error
+}
+
+func (e *RowError) Error() string {
+	if e.Column != "" {
+		return fmt.Sprintf("строка %d, колонка %s: %v", e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("строка %d: %v", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error { return e.Err }
+
+// importColumn колонка таблицы и номер поля записи файла.
+type importColumn struct {
+	name  string
+	kind  columnKind
+	field int
+}
+
+// importRecord запись файла: строки CSV (nil - NULL) или значения JSON.
+type importRecord struct {
+	line   int
+	values []any
+}
+
+// importSource чтение записей файла.
+type importSource interface {
+	// fields имена полей записей.
+	fields() []string
+	// next следующая запись, io.EOF - записей больше нет. Ошибка *RowError не прерывает чтение.
+	next() (importRecord, error)
+}
+
+// ImportContext загрузка CSV или JSON Lines из r в таблицу table.
+// Значения приводятся к типам колонок таблицы, строки загружаются пакетами: в postgres (lib/pq)
+// и sqlserver через COPY / bulk copy, в остальных БД запросами INSERT на несколько строк.
+// Строки с ошибками (формат значения, ограничения таблицы) пропускаются и возвращаются в ImportResult.Errors,
+// после MaxErrors ошибок загрузка прерывается с ErrImportErrors. В транзакции Tx ошибка пакета прерывает загрузку.
+//
+// res, err := db.ImportContext(ctx, file, "sales", dbwrap.ImportOptions{Format: dbwrap.ExportCSV, Comma: ';'})
+func (d *DBSQL) ImportContext(ctx context.Context, r io.Reader, table string, opts ImportOptions) (*ImportResult, error) {
+	var src importSource
+	switch opts.Format {
+	case ExportCSV:
+		cr := csv.NewReader(r)
+		if opts.Comma != 0 {
+			cr.Comma = opts.Comma
+		}
+		cr.ReuseRecord = true
+		s, err := newCSVSource(cr, &opts)
+		if err != nil {
+			return nil, fmt.Errorf("import %s: %w", table, err)
+		}
+		src = s
+	case ExportJSONL:
+		s, err := newJSONLSource(bufio.NewReader(r), &opts)
+		if err != nil {
+			return nil, fmt.Errorf("import %s: %w", table, err)
+		}
+		src = s
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrImportFormat, opts.Format)
+	}
+
+	var im *importer
+	if opts.Format == ExportJSONL && opts.Columns == nil && len(src.fields()) == 0 {
+		// в JSON Lines нет ни одного объекта, возвращаются только ошибки строк
+		im = &importer{opts: &opts, res: &ImportResult{}}
+	} else {
+		cols, err := d.importColumns(ctx, table, src.fields(), opts.Columns)
+		if err != nil {
+			return nil, fmt.Errorf("import %s: %w", table, err)
+		}
+		im = newImporter(d, table, cols, &opts)
+	}
+	err := im.run(ctx, src)
+	if err != nil {
+		err = fmt.Errorf("import %s: %w", table, err)
+	}
+	// ошибки пакетов обнаруживаются позже ошибок чтения
+	slices.SortStableFunc(im.res.Errors, func(a, b *RowError) int { return a.Line - b.Line })
+	return im.res, err
+}
+
+// importColumns колонки таблицы для полей файла с типами из результата запроса к таблице.
+func (d *DBSQL) importColumns(ctx context.Context, table string, fields []string, mapping map[string]string) ([]importColumn, error) {
+	rows, err := d.ext().QueryxContext(ctx, "SELECT * FROM "+d.Dialect().QuoteIdent(table)+" WHERE 1 = 0")
+	if err != nil {
+		return nil, err
+	}
+	types, err := rows.ColumnTypes()
+	if err = multierr.Append(err, rows.Close()); err != nil {
+		return nil, err
+	}
+
+	var cols []importColumn
+	for i, f := range fields {
+		name := f
+		if mapping != nil {
+			if name = mapping[f]; name == "" {
+				continue
+			}
+		}
+		found := false
+		for _, ct := range types {
+			if strings.EqualFold(ct.Name(), name) {
+				c := &Column{}
+				c.DataType, c.Length, c.Precision, c.Scale = parseColumnType(ct.DatabaseTypeName())
+				cols = append(cols, importColumn{name: ct.Name(), kind: kindOf(d.Dialect().Name(), c), field: i})
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %s.%s", ErrImportColumn, table, name)
+		}
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("%w: нет полей для загрузки", ErrImportColumn)
+	}
+	return cols, nil
+}
+
+// importer накопление строк в пакеты и загрузка в таблицу.
+type importer struct {
+	d     *DBSQL
+	table string
+	cols  []importColumn
+	names []string
+	opts  *ImportOptions
+	res   *ImportResult
+	lines []int
+	rows  [][]any
+}
+
+func newImporter(d *DBSQL, table string, cols []importColumn, opts *ImportOptions) *importer {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+	switch d.Dialect().Name() {
+	case "postgres", "sqlserver":
+	default:
+		// пакет загружается одним запросом INSERT, количество строк ограничено количеством параметров
+		opts.BatchSize = min(opts.BatchSize, max(1, d.Dialect().MaxParams()/len(cols)))
+	}
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = c.name
+	}
+	return &importer{d: d, table: table, cols: cols, names: names, opts: opts, res: &ImportResult{}}
+}
+
+func (im *importer) run(ctx context.Context, src importSource) error {
+	for {
+		rec, err := src.next()
+		if err == io.EOF {
+			break
+		}
+		var rowErr *RowError
+		switch {
+		case errors.As(err, &rowErr):
+			err = im.fail(rowErr)
+		case err == nil:
+			err = im.add(ctx, rec)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return im.flush(ctx)
+}
+
+func (im *importer) add(ctx context.Context, rec importRecord) error {
+	row := make([]any, len(im.cols))
+	for i, c := range im.cols {
+		v, err := importValue(c.kind, rec.values[c.field], im.opts)
+		if err != nil {
+			return im.fail(&RowError{Line: rec.line, Column: c.name, Err: err})
+		}
+		row[i] = v
+	}
+	im.lines = append(im.lines, rec.line)
+	im.rows = append(im.rows, row)
+	if len(im.rows) < im.opts.BatchSize {
+		return nil
+	}
+	return im.flush(ctx)
+}
+
+// fail ошибка строки, после MaxErrors ошибок загрузка прерывается.
+func (im *importer) fail(e *RowError) error {
+	im.res.Errors = append(im.res.Errors, e)
+	if im.opts.MaxErrors > 0 && len(im.res.Errors) >= im.opts.MaxErrors {
+		return fmt.Errorf("%w: %w", ErrImportErrors, e)
+	}
+	return nil
+}
+
+// flush загрузка накопленного пакета. При ошибке пакет (вне транзакции Tx он не загружен)
+// загружается по одной строке, чтобы найти строки с ошибками.
+func (im *importer) flush(ctx context.Context) error {
+	if len(im.rows) == 0 {
+		return nil
+	}
+	lines, rows := im.lines, im.rows
+	im.lines, im.rows = im.lines[:0], im.rows[:0]
+
+	err := im.load(ctx, rows)
+	if err == nil {
+		im.res.Rows += int64(len(rows))
+		return nil
+	}
+	if im.d.tx != nil {
+		return err
+	}
+	for i, row := range rows {
+		if err := im.d.importExec(ctx, im.insertQuery(1), row); err != nil {
+			if err := im.fail(&RowError{Line: lines[i], Err: err}); err != nil {
+				return err
+			}
+			continue
+		}
+		im.res.Rows++
+	}
+	return nil
+}
+
+func (im *importer) load(ctx context.Context, rows [][]any) error {
+	switch im.d.Dialect().Name() {
+	case "postgres":
+		// lib/pq выполняет COPY FROM STDIN подготовленным запросом
+		return im.d.copyIn(ctx, fmt.Sprintf("COPY %s (%s) FROM STDIN", im.d.Dialect().QuoteIdent(im.table),
+			quoteList(im.d.Dialect(), im.names)), rows)
+	case "sqlserver":
+		return im.d.copyIn(ctx, mssql.CopyIn(im.d.Dialect().QuoteIdent(im.table), mssql.BulkOptions{}, im.names...), rows)
+	}
+
+	args := make([]any, 0, len(rows)*len(im.cols))
+	for _, row := range rows {
+		args = append(args, row...)
+	}
+	return im.d.importExec(ctx, im.insertQuery(len(rows)), args)
+}
+
+// insertQuery запрос INSERT на n строк.
+func (im *importer) insertQuery(n int) string {
+	var sb strings.Builder
+	q := im.d.Dialect()
+	fmt.Fprintf(&sb, "INSERT INTO %s (%s) VALUES ", q.QuoteIdent(im.table), quoteList(q, im.names))
+	p := 0
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteByte('(')
+		for j := range im.cols {
+			if j > 0 {
+				sb.WriteString(", ")
+			}
+			p++
+			sb.WriteString(q.Placeholder(p))
+		}
+		sb.WriteByte(')')
+	}
+	return sb.String()
+}
+
+// importExec выполнение запроса загрузки, параметры не включаются в текст ошибки.
+func (d *DBSQL) importExec(ctx context.Context, query string, args []any) error {
+	ctx, cancel := d.queryContext(ctx)
+	defer cancel()
+	start := time.Now()
+
+	_, err := d.ext().ExecContext(ctx, query, args...)
+	return d.done(ctx, start, err, query)
+}
+
+// copyIn загрузка строк подготовленным запросом копирования драйвера в транзакции.
+func (d *DBSQL) copyIn(ctx context.Context, query string, rows [][]any) (err error) {
+	ctx, cancel := d.queryContext(ctx)
+	defer cancel()
+	start := time.Now()
+
+	tx := d.tx
+	if tx == nil {
+		if tx, err = d.DBX.BeginTxx(ctx, nil); err != nil {
+			return d.done(ctx, start, err, query)
+		}
+	}
+	err = execCopy(ctx, tx, query, rows)
+	if d.tx == nil {
+		if err != nil {
+			err = multierr.Append(err, tx.Rollback())
+		} else {
+			err = tx.Commit()
+		}
+	}
+	return d.done(ctx, start, err, query)
+}
+
+func execCopy(ctx context.Context, tx *sqlx.Tx, query string, rows [][]any) (err error) {
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = multierr.Append(err, stmt.Close())
+	}()
+	for _, row := range rows {
+		if _, err = stmt.ExecContext(ctx, row...); err != nil {
+			return err
+		}
+	}
+	// завершение копирования
+	_, err = stmt.ExecContext(ctx)
+	return err
+}
+
+// importValue значение поля файла v в типе колонки вида kind.
+func importValue(kind columnKind, v any, opts *ImportOptions) (any, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return importText(kind, v, opts)
+	case json.Number:
+		switch kind {
+		case kindString, kindUnknown:
+			return v.String(), nil
+		}
+		return importText(kind, v.String(), opts)
+	case bool:
+		switch kind {
+		case kindBool:
+			return v, nil
+		case kindString, kindUnknown:
+			return strconv.FormatBool(v), nil
+		case kindInt, kindBigInt:
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+		return nil, fmt.Errorf("логическое значение для колонки типа %s", kind)
+	}
+	// объекты и массивы JSON в текстовые колонки (json, jsonb)
+	switch kind {
+	case kindString, kindUnknown:
+		data, err := json.Marshal(v)
+		return string(data), err
+	}
+	return nil, fmt.Errorf("значение %T для колонки типа %s", v, kind)
+}
+
+// importText значение из текста s в типе колонки вида kind.
+func importText(kind columnKind, s string, opts *ImportOptions) (any, error) {
+	switch kind {
+	case kindString, kindUnknown:
+		return s, nil
+	case kindBytes:
+		return []byte(s), nil
+	}
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	switch kind {
+	case kindTime, kindUUID:
+		return s, nil
+	case kindBool:
+		return strconv.ParseBool(s)
+	case kindInt, kindBigInt:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			if b, errBool := strconv.ParseBool(s); errBool == nil {
+				return importValue(kind, b, opts)
+			}
+			return nil, err
+		}
+		return n, nil
+	case kindFloat, kindDecimal:
+		if opts.DecimalSeparator != "" {
+			s = strings.Replace(s, opts.DecimalSeparator, ".", 1)
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+		if kind == kindDecimal {
+			return s, nil // без потери точности
+		}
+		return f, nil
+	case kindDate:
+		return parseTime(s, opts.DateFormat, time.DateOnly, time.RFC3339Nano, time.DateTime)
+	}
+	if opts.TimeFormat != "" {
+		return parseTime(s, opts.TimeFormat)
+	}
+	return parseTime(s, time.RFC3339Nano, "2006-01-02 15:04:05.999999999Z07:00", "2006-01-02 15:04:05.999999999",
+		"2006-01-02T15:04:05.999999999", time.DateOnly)
+}
+
+// parseTime разбор времени по первому подходящему формату, пустые форматы пропускаются.
+func parseTime(s string, layouts ...string) (t time.Time, err error) {
+	for _, layout := range layouts {
+		if layout == "" {
+			continue
+		}
+		if t, err = time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return t, err
+}
+
+// csvSource записи CSV, первая строка - заголовок.
+type csvSource struct {
+	r      *csv.Reader
+	header []string
+	opts   *ImportOptions
+}
+
+func newCSVSource(r *csv.Reader, opts *ImportOptions) (*csvSource, error) {
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, errors.New("нет заголовка CSV")
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := &csvSource{r: r, header: make([]string, len(header)), opts: opts}
+	for i, h := range header {
+		s.header[i] = strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))
+	}
+	for f := range opts.Columns {
+		if !slices.Contains(s.header, f) {
+			return nil, fmt.Errorf("%w: поле %s отсутствует в заголовке CSV", ErrImportColumn, f)
+		}
+	}
+	r.FieldsPerRecord = len(header)
+	return s, nil
+}
+
+func (s *csvSource) fields() []string { return s.header }
+
+func (s *csvSource) next() (importRecord, error) {
+	record, err := s.r.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && parseErr.Err == csv.ErrFieldCount {
+			return importRecord{}, &RowError{Line: parseErr.StartLine, Err: parseErr.Err}
+		}
+		return importRecord{}, err
+	}
+	line, _ := s.r.FieldPos(0)
+	rec := importRecord{line: line, values: make([]any, len(record))}
+	for i, v := range record {
+		if v != s.opts.Null {
+			rec.values[i] = v
+		}
+	}
+	return rec, nil
+}
+
+// jsonlSource записи JSON Lines. Поля - ключи Columns или первого объекта файла.
+type jsonlSource struct {
+	r       *bufio.Reader
+	names   []string
+	line    int
+	pending []*RowError // ошибки строк до первого объекта
+	first   *importRecord
+	known   bool // поля заданы Columns, остальные ключи не загружаются
+	stopped bool // достигнут MaxErrors до первого объекта
+}
+
+func newJSONLSource(r *bufio.Reader, opts *ImportOptions) (*jsonlSource, error) {
+	s := &jsonlSource{r: r}
+	for f := range opts.Columns {
+		s.names = append(s.names, f)
+	}
+	s.known = opts.Columns != nil
+	if !s.known {
+		// поля по первому объекту, строки с ошибками до него возвращаются next
+		var (
+			obj  map[string]any
+			line int
+			err  error
+		)
+		for {
+			obj, line, err = s.read()
+			var rowErr *RowError
+			if !errors.As(err, &rowErr) {
+				break
+			}
+			s.pending = append(s.pending, rowErr)
+			if opts.MaxErrors > 0 && len(s.pending) >= opts.MaxErrors {
+				s.stopped = true
+				return s, nil
+			}
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		for k := range obj {
+			s.names = append(s.names, k)
+		}
+		sort.Strings(s.names)
+		if obj != nil {
+			rec, err := s.record(obj, line)
+			if err != nil {
+				return nil, err
+			}
+			s.first = &rec
+		}
+		return s, nil
+	}
+	sort.Strings(s.names)
+	return s, nil
+}
+
+func (s *jsonlSource) fields() []string { return s.names }
+
+func (s *jsonlSource) next() (importRecord, error) {
+	if len(s.pending) > 0 {
+		e := s.pending[0]
+		s.pending = s.pending[1:]
+		return importRecord{}, e
+	}
+	if s.stopped {
+		return importRecord{}, io.EOF
+	}
+	if s.first != nil {
+		rec := *s.first
+		s.first = nil
+		return rec, nil
+	}
+	obj, line, err := s.read()
+	if err != nil {
+		return importRecord{}, err
+	}
+	return s.record(obj, line)
+}
+
+// read следующий объект JSON, пустые строки пропускаются.
+func (s *jsonlSource) read() (map[string]any, int, error) {
+	for {
+		data, err := s.r.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(data) == 0) {
+			return nil, 0, err
+		}
+		s.line++
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var obj map[string]any
+		if err := dec.Decode(&obj); err != nil {
+			return nil, s.line, &RowError{Line: s.line, Err: err}
+		}
+		return obj, s.line, nil
+	}
+}
+
+func (s *jsonlSource) record(obj map[string]any, line int) (importRecord, error) {
+	rec := importRecord{line: line, values: make([]any, len(s.names))}
+	for k, v := range obj {
+		i := sort.SearchStrings(s.names, k)
+		if i == len(s.names) || s.names[i] != k {
+			if s.known {
+				continue
+			}
+			return importRecord{}, &RowError{Line: line, Err: fmt.Errorf("%w: поле %s", ErrImportColumn, k)}
+		}
+		rec.values[i] = v
+	}
+	return rec, nil
+}
@@ -0,0 +1,49 @@
+package dbwrap
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportValue(t *testing.T) {
+	opts := &ImportOptions{DecimalSeparator: ","}
+	tests := []struct {
+		kind columnKind
+		in   any
+		want any
+	}{
+		{kindInt, " 42 ", int64(42)},
+		{kindInt, "true", int64(1)},
+		{kindBigInt, json.Number("9007199254740993"), int64(9007199254740993)},
+		{kindBool, "0", false},
+		{kindFloat, "1,5", 1.5},
+		{kindDecimal, "1000,10", "1000.10"},
+		{kindDecimal, json.Number("0.1"), "0.1"},
+		{kindDate, "2024-01-02", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{kindTimestamp, "2024-01-02 03:04:05", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{kindTimestamp, "", nil},
+		{kindString, "", ""},
+		{kindString, json.Number("1.50"), "1.50"},
+		{kindString, []any{"a", json.Number("1")}, `["a",1]`},
+		{kindBytes, "abc", []byte("abc")},
+		{kindUUID, "", nil},
+	}
+	for _, tt := range tests {
+		got, err := importValue(tt.kind, tt.in, opts)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+
+	_, err := importValue(kindInt, "1.5", opts)
+	assert.Error(t, err)
+	_, err = importValue(kindDate, true, opts)
+	assert.Error(t, err)
+
+	got, err := importValue(kindDate, "02.01.2024", &ImportOptions{DateFormat: "02.01.2006"})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), got)
+}
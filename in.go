@@ -0,0 +1,252 @@
+package dbwrap
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	mssql "github.com/microsoft/go-mssqldb"
+)
+
+// ErrEmptyInList ошибка.
+var ErrEmptyInList = errors.New("пустой список значений для IN")
+
+// InOptions параметры раскрытия слайсов в списки IN.
+type InOptions struct {
+	// ArrayThreshold длина списка, начиная с которой вместо раскрытия в отдельные параметры
+	// список передаётся одним параметром: для postgres "IN (?)" заменяется на "= ANY(?)",
+	// для sqlserver используется табличный параметр из TVPTypes. 0 - списки всегда раскрываются.
+	ArrayThreshold int
+	// TVPTypes имена табличных типов SQL Server с одной колонкой по типу элемента слайса Go,
+	// например {"int": "dbo.IntList"} для create type dbo.IntList as table (value int).
+	TVPTypes map[string]string
+}
+
+// SetInOptions установка параметров раскрытия слайсов в списки IN.
+func (d *DBSQL) SetInOptions(opt InOptions) {
+	d.inOptions = opt
+}
+
+var (
+	reInPrefix = regexp.MustCompile(`(?i)\b(NOT\s+)?IN\s*\(\s*$`)
+	reInSuffix = regexp.MustCompile(`^\s*\)`)
+)
+
+// expandIn раскрытие слайсов в аргументах запроса: "id in (?)" c []int{1,2} -> "id in (?, ?)".
+// bindType задаёт вид параметров в запросе, для sqlx.QUESTION результат также содержит "?".
+// При ошибке возвращаются исходные запрос и аргументы.
+func (d *DBSQL) expandIn(bindType int, query string, args []any) (string, []any, error) {
+	hasSlices := false
+	for _, arg := range args {
+		if _, ok := asSliceForIn(arg); ok {
+			hasSlices = true
+			break
+		}
+	}
+	if !hasSlices || bindType == sqlx.NAMED || bindType == sqlx.UNKNOWN {
+		return query, args, nil
+	}
+
+	dialect := d.Dialect()
+	placeholder := func(n int) string {
+		if bindType == sqlx.QUESTION {
+			return "?"
+		}
+		return dialect.Placeholder(n)
+	}
+
+	var (
+		sb      strings.Builder
+		newArgs = make([]any, 0, len(args))
+		next    int
+	)
+	for pos := 0; pos < len(query); {
+		start, end, ref, found := nextBindVar(bindType, query, pos)
+		if !found {
+			sb.WriteString(query[pos:])
+			break
+		}
+		sb.WriteString(query[pos:start])
+		pos = end
+
+		idx := ref - 1
+		if bindType == sqlx.QUESTION {
+			idx = next
+			next++
+		}
+		if idx < 0 || idx >= len(args) {
+			return query, args, fmt.Errorf("expand in: параметр %s без значения", query[start:end])
+		}
+
+		v, isSlice := asSliceForIn(args[idx])
+		if !isSlice {
+			newArgs = append(newArgs, args[idx])
+			sb.WriteString(placeholder(len(newArgs)))
+			continue
+		}
+		if v.Len() == 0 {
+			return query, args, ErrEmptyInList
+		}
+
+		if d.inOptions.ArrayThreshold > 0 && v.Len() >= d.inOptions.ArrayThreshold &&
+			reInPrefix.MatchString(sb.String()) && reInSuffix.MatchString(query[pos:]) {
+			if arg, ok := d.arrayArg(v); ok {
+				newArgs = append(newArgs, arg)
+				writeArrayIn(&sb, dialect.Name(), placeholder(len(newArgs)))
+				continue
+			}
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			newArgs = append(newArgs, v.Index(i).Interface())
+			sb.WriteString(placeholder(len(newArgs)))
+		}
+	}
+	if bindType == sqlx.QUESTION && next != len(args) {
+		return query, args, fmt.Errorf("expand in: параметров в запросе %d, передано значений %d", next, len(args))
+	}
+
+	return sb.String(), newArgs, nil
+}
+
+// nextBindVar поиск очередного параметра запроса начиная с pos без учёта строковых констант.
+// ref - номер параметра для $N и @pN.
+func nextBindVar(bindType int, query string, pos int) (start, end, ref int, found bool) {
+	inQuote := false
+	for i := pos; i < len(query); i++ {
+		c := query[i]
+		if c == '\'' {
+			inQuote = !inQuote
+			continue
+		}
+		if inQuote {
+			continue
+		}
+		switch {
+		case bindType == sqlx.QUESTION && c == '?':
+			return i, i + 1, 0, true
+		case bindType == sqlx.DOLLAR && c == '$':
+			if n, l := leadingNumber(query[i+1:]); l > 0 {
+				return i, i + 1 + l, n, true
+			}
+		case bindType == sqlx.AT && c == '@' && i+1 < len(query) && (query[i+1] == 'p' || query[i+1] == 'P'):
+			if n, l := leadingNumber(query[i+2:]); l > 0 {
+				return i, i + 2 + l, n, true
+			}
+		}
+	}
+	return 0, 0, 0, false
+}
+
+func leadingNumber(s string) (n, length int) {
+	for length < len(s) && s[length] >= '0' && s[length] <= '9' {
+		length++
+	}
+	if length == 0 {
+		return 0, 0
+	}
+	n, _ = strconv.Atoi(s[:length])
+	return n, length
+}
+
+// writeArrayIn замена "IN (" на выражение с параметром-массивом.
+func writeArrayIn(sb *strings.Builder, driverName, placeholder string) {
+	prefix := sb.String()
+	loc := reInPrefix.FindStringSubmatchIndex(prefix)
+	sb.Reset()
+	sb.WriteString(prefix[:loc[0]])
+	negate := loc[2] >= 0
+	switch driverName {
+	case "postgres":
+		if negate {
+			sb.WriteString("<> ALL(" + placeholder)
+		} else {
+			sb.WriteString("= ANY(" + placeholder)
+		}
+	default:
+		if negate {
+			sb.WriteString("NOT ")
+		}
+		sb.WriteString("IN (SELECT * FROM " + placeholder)
+	}
+}
+
+// arrayArg значение списка одним параметром: массив postgres или табличный параметр sqlserver.
+func (d *DBSQL) arrayArg(v reflect.Value) (any, bool) {
+	switch d.Dialect().Name() {
+	case "postgres":
+		return pgArray{v: v}, true
+	case "sqlserver":
+		typeName, ok := d.inOptions.TVPTypes[v.Type().Elem().String()]
+		if !ok {
+			return nil, false
+		}
+		rowType := reflect.StructOf([]reflect.StructField{{Name: "Value", Type: v.Type().Elem()}})
+		rows := reflect.MakeSlice(reflect.SliceOf(rowType), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			rows.Index(i).Field(0).Set(v.Index(i))
+		}
+		return mssql.TVP{TypeName: typeName, Value: rows.Interface()}, true
+	}
+	return nil, false
+}
+
+func asSliceForIn(arg any) (reflect.Value, bool) {
+	if arg == nil {
+		return reflect.Value{}, false
+	}
+	if _, ok := arg.(driver.Valuer); ok {
+		return reflect.Value{}, false
+	}
+	v := reflect.Indirect(reflect.ValueOf(arg))
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() == reflect.Uint8 {
+		return reflect.Value{}, false
+	}
+	return v, true
+}
+
+// pgArray литерал массива postgres {1,2,3} для параметра = ANY($1).
+type pgArray struct {
+	v reflect.Value
+}
+
+// Value реализация driver.Valuer.
+func (a pgArray) Value() (driver.Value, error) {
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i := 0; i < a.v.Len(); i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		el := reflect.Indirect(a.v.Index(i))
+		if !el.IsValid() {
+			sb.WriteString("NULL")
+			continue
+		}
+		switch x := el.Interface().(type) {
+		case string:
+			sb.WriteString(`"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(x) + `"`)
+		case time.Time:
+			sb.WriteString(`"` + x.Format(time.RFC3339Nano) + `"`)
+		case bool:
+			if x {
+				sb.WriteByte('t')
+			} else {
+				sb.WriteByte('f')
+			}
+		default:
+			fmt.Fprint(&sb, x)
+		}
+	}
+	sb.WriteByte('}')
+	return sb.String(), nil
+}
@@ -0,0 +1,66 @@
+package dbwrap
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandIn(t *testing.T) {
+
+	var tests = []struct {
+		dialect   Dialect
+		bindType  int
+		query     string
+		args      []any
+		wantQuery string
+		wantArgs  []any
+	}{
+		{sqlite3Dialect{}, sqlx.QUESTION, "select * from t where id in (?) and name = ?", []any{[]int{1, 2, 3}, "a"},
+			"select * from t where id in (?, ?, ?) and name = ?", []any{1, 2, 3, "a"}},
+		{postgresDialect{}, sqlx.DOLLAR, "select * from t where name = $2 and id in ($1) and x = '$1'", []any{[]int{1, 2}, "a"},
+			"select * from t where name = $1 and id in ($2, $3) and x = '$1'", []any{"a", 1, 2}},
+		{sqlserverDialect{}, sqlx.AT, "select * from t where id in (@p1)", []any{[]string{"a", "b"}},
+			"select * from t where id in (@p1, @p2)", []any{"a", "b"}},
+		{postgresDialect{}, sqlx.DOLLAR, "select * from t where id = $1", []any{[]byte("x")},
+			"select * from t where id = $1", []any{[]byte("x")}},
+	}
+	for _, test := range tests {
+		d := &DBSQL{dialect: test.dialect}
+		q, args, err := d.expandIn(test.bindType, test.query, test.args)
+		require.NoError(t, err)
+		assert.Equal(t, test.wantQuery, q)
+		assert.Equal(t, test.wantArgs, args)
+	}
+
+	d := &DBSQL{dialect: sqlite3Dialect{}}
+	_, _, err := d.expandIn(sqlx.QUESTION, "select * from t where id in (?)", []any{[]int{}})
+	assert.ErrorIs(t, err, ErrEmptyInList)
+}
+
+func TestExpandInArray(t *testing.T) {
+	opt := InOptions{ArrayThreshold: 3, TVPTypes: map[string]string{"int": "dbo.IntList"}}
+
+	d := &DBSQL{dialect: postgresDialect{}, inOptions: opt}
+	q, args, err := d.expandIn(sqlx.QUESTION, "select * from t where id IN (?) or id not in ( ? ) or id in (?)", []any{[]int{1, 2, 3}, []int{4, 5, 6}, []int{7}})
+	require.NoError(t, err)
+	assert.Equal(t, "select * from t where id = ANY(?) or id <> ALL(? ) or id in (?)", q)
+	require.Len(t, args, 3)
+	v, err := args[0].(pgArray).Value()
+	require.NoError(t, err)
+	assert.Equal(t, "{1,2,3}", v)
+
+	v, err = pgArray{v: reflect.ValueOf([]string{`a"b`, "c"})}.Value()
+	require.NoError(t, err)
+	assert.Equal(t, `{"a\"b","c"}`, v)
+
+	d = &DBSQL{dialect: sqlserverDialect{}, inOptions: opt}
+	q, args, err = d.expandIn(sqlx.AT, "select * from t where id in (@p1)", []any{[]int{1, 2, 3}})
+	require.NoError(t, err)
+	assert.Equal(t, "select * from t where id IN (SELECT * FROM @p1)", q)
+	assert.Equal(t, "dbo.IntList", args[0].(mssql.TVP).TypeName)
+}
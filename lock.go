@@ -0,0 +1,210 @@
+package dbwrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/multierr"
+)
+
+// ErrLockTimeout ошибка.
+var ErrLockTimeout = errors.New("блокировка не получена за отведённое время")
+
+// LockTable таблица блокировок для драйверов без блокировок сеанса (sqlite3).
+const LockTable = "dbwrap_locks"
+
+// lockRetry паузы между попытками получить занятую блокировку.
+const (
+	lockRetryMin = 50 * time.Millisecond
+	lockRetryMax = time.Second
+)
+
+// lockTableTTL срок, после которого непродлённая строка dbwrap_locks считается брошенной.
+const lockTableTTL = time.Minute
+
+// ReleaseFunc снятие блокировки.
+type ReleaseFunc func() error
+
+// Lock распределённая блокировка name: pg_advisory_lock (postgres), sp_getapplock (sqlserver),
+// GET_LOCK (mysql), строка таблицы dbwrap_locks (sqlite3 и другие драйверы).
+// Ожидание занятой блокировки ограничено сроком ctx, после него возвращается ErrLockTimeout.
+// Блокировки postgres, sqlserver и mysql удерживает отдельное соединение пула до вызова release,
+// при разрыве соединения блокировка снимается сервером.
+//
+// ctx, cancel := context.WithTimeout(ctx, time.Minute)
+//
+// defer cancel()
+//
+// release, err := db.Lock(ctx, "billing.daily_report")
+//
+// defer release()
+func (d *DBSQL) Lock(ctx context.Context, name string) (ReleaseFunc, error) {
+	delay := lockRetryMin
+	for {
+		release, ok, err := d.TryLock(ctx, name)
+		if err != nil && ctx.Err() != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrLockTimeout, name, ctx.Err())
+		}
+		if err != nil || ok {
+			return release, err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w: %s: %w", ErrLockTimeout, name, ctx.Err())
+		case <-timer.C:
+		}
+		delay = min(delay*2, lockRetryMax)
+	}
+}
+
+// TryLock попытка получить блокировку name без ожидания, ok=false - блокировка занята.
+func (d *DBSQL) TryLock(ctx context.Context, name string) (release ReleaseFunc, ok bool, err error) {
+	driver := d.Dialect().Name()
+	switch driver {
+	case "postgres", "mysql", "sqlserver":
+	default:
+		return d.tryLockTable(ctx, name)
+	}
+
+	conn, err := d.DBX.Connx(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("lock %s: %w", name, err)
+	}
+	var unlock string
+	var args []any
+	switch driver {
+	case "postgres":
+		key := lockKey(name)
+		err = conn.GetContext(ctx, &ok, "SELECT pg_try_advisory_lock($1)", key)
+		unlock, args = "SELECT pg_advisory_unlock($1)", []any{key}
+	case "mysql":
+		var res *int
+		err = conn.GetContext(ctx, &res, "SELECT GET_LOCK(?, 0)", name)
+		ok = res != nil && *res == 1
+		unlock, args = "SELECT RELEASE_LOCK(?)", []any{name}
+	case "sqlserver":
+		var res int
+		err = conn.GetContext(ctx, &res, `DECLARE @res int;
+EXEC @res = sp_getapplock @Resource = @p1, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = 0;
+SELECT @res`, name)
+		ok = res >= 0
+		unlock, args = "EXEC sp_releaseapplock @Resource = @p1, @LockOwner = 'Session'", []any{name}
+	}
+	if err != nil || !ok {
+		if err != nil {
+			err = fmt.Errorf("lock %s: %w", name, err)
+		}
+		return nil, false, multierr.Append(err, conn.Close())
+	}
+	return sessionRelease(conn, unlock, args), true, nil
+}
+
+// lockKey ключ pg_advisory_lock по имени блокировки.
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+// sessionRelease снятие блокировки сеанса и возврат соединения в пул, повторный вызов ничего не делает.
+func sessionRelease(conn *sqlx.Conn, query string, args []any) ReleaseFunc {
+	released := false
+	return func() error {
+		if released {
+			return nil
+		}
+		released = true
+		_, err := conn.ExecContext(context.Background(), query, args...)
+		if err != nil {
+			err = fmt.Errorf("unlock: %w", err)
+		}
+		return multierr.Append(err, conn.Close())
+	}
+}
+
+// tryLockTable блокировка строкой таблицы dbwrap_locks. Пока блокировка удерживается, locked_at
+// обновляется каждые lockTableTTL/3 до release или первой ошибки обновления, строку процесса,
+// завершившегося без release, другой процесс удаляет через lockTableTTL.
+func (d *DBSQL) tryLockTable(ctx context.Context, name string) (ReleaseFunc, bool, error) {
+	table := d.Dialect().QuoteIdent(LockTable)
+	_, err := d.DBX.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (name VARCHAR(255) NOT NULL PRIMARY KEY, locked_at TIMESTAMP NOT NULL)", table))
+	if err != nil {
+		return nil, false, fmt.Errorf("lock %s: %w", name, err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	_, err = d.DBX.ExecContext(ctx, d.DBX.Rebind(fmt.Sprintf("DELETE FROM %s WHERE name = ? AND locked_at < ?", table)),
+		name, now.Add(-lockTableTTL))
+	if err != nil {
+		return nil, false, fmt.Errorf("lock %s: %w", name, err)
+	}
+	res, err := d.DBX.ExecContext(ctx, d.DBX.Rebind(fmt.Sprintf(
+		"INSERT INTO %[1]s (name, locked_at) SELECT ?, ? WHERE NOT EXISTS (SELECT 1 FROM %[1]s WHERE name = ?)", table)),
+		name, now, name)
+	if err != nil {
+		// строку успел вставить другой процесс
+		if isUniqueViolation(err) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("lock %s: %w", name, err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		if err != nil {
+			err = fmt.Errorf("lock %s: %w", name, err)
+		}
+		return nil, false, err
+	}
+
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(lockTableTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case t := <-ticker.C:
+				// после ошибки (закрытый DBSQL, release не вызван) или удаления строки продление прекращается,
+				// строка устаревает через lockTableTTL
+				res, err := d.DBX.ExecContext(context.Background(), d.DBX.Rebind(fmt.Sprintf(
+					"UPDATE %s SET locked_at = ? WHERE name = ?", table)), t.UTC().Truncate(time.Second), name)
+				if err != nil {
+					return
+				}
+				if n, err := res.RowsAffected(); err != nil || n == 0 {
+					return
+				}
+			}
+		}
+	}()
+
+	released := false
+	return func() error {
+		if released {
+			return nil
+		}
+		released = true
+		close(stop)
+		<-stopped
+		_, err := d.DBX.ExecContext(context.Background(), d.DBX.Rebind(fmt.Sprintf("DELETE FROM %s WHERE name = ?", table)), name)
+		if err != nil {
+			return fmt.Errorf("unlock %s: %w", name, err)
+		}
+		return nil
+	}, true, nil
+}
+
+// isUniqueViolation нарушение первичного ключа или уникального индекса по тексту ошибки драйвера.
+func isUniqueViolation(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "unique") || strings.Contains(msg, "duplicate") || strings.Contains(msg, "primary key")
+}
@@ -0,0 +1,86 @@
+package migrations
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mpuzanov/dbwrap"
+)
+
+// transactionalDDL драйверы, в которых DDL выполняется в транзакции и отменяется при ошибке.
+var transactionalDDL = map[string]bool{
+	"sqlserver": true,
+	"postgres":  true,
+	"sqlite3":   true,
+}
+
+// createTableSQL создание таблицы применённых версий в синтаксисе диалекта.
+func createTableSQL(d dbwrap.Dialect, table string) string {
+	q := d.QuoteIdent(table)
+	switch d.Name() {
+	case "sqlserver":
+		return fmt.Sprintf(`IF OBJECT_ID(N'%s', N'U') IS NULL
+CREATE TABLE %s (
+	version BIGINT NOT NULL PRIMARY KEY,
+	name NVARCHAR(255) NOT NULL,
+	applied_at DATETIME2 NOT NULL
+)`, strings.ReplaceAll(table, "'", "''"), q)
+	case "mysql":
+		return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	version BIGINT NOT NULL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	applied_at DATETIME(6) NOT NULL
+)`, q)
+	default:
+		return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	version BIGINT NOT NULL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	applied_at TIMESTAMP NOT NULL
+)`, q)
+	}
+}
+
+var goSeparator = regexp.MustCompile(`(?im)^[ \t]*GO[ \t]*(?:--.*)?$`)
+
+// splitStatements разбиение SQL миграции на запросы:
+// sqlserver - пакеты, разделённые строкой GO;
+// mysql - запросы, заканчивающиеся ";" в конце строки (драйвер без multiStatements);
+// остальные драйверы выполняют текст одним запросом.
+func splitStatements(driver, text string) []string {
+	var parts []string
+	switch driver {
+	case "sqlserver":
+		parts = goSeparator.Split(text, -1)
+	case "mysql":
+		var sb strings.Builder
+		for _, line := range strings.SplitAfter(text, "\n") {
+			sb.WriteString(line)
+			if strings.HasSuffix(strings.TrimSpace(line), ";") {
+				parts = append(parts, sb.String())
+				sb.Reset()
+			}
+		}
+		parts = append(parts, sb.String())
+	default:
+		parts = []string{text}
+	}
+
+	res := parts[:0]
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" && !onlyComments(p) {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// onlyComments текст без запросов, только строчные комментарии.
+func onlyComments(text string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,20 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mpuzanov/dbwrap"
+)
+
+// lock блокировка миграций на время выполнения через DBSQL.Lock,
+// чтобы несколько процессов не применяли их одновременно.
+func (m *Migrator) lock(ctx context.Context) (dbwrap.ReleaseFunc, error) {
+	lockCtx, cancel := context.WithTimeout(ctx, m.lockTimeout)
+	defer cancel()
+	release, err := m.db.Lock(lockCtx, "dbwrap.migrations."+m.table)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrLocked, err)
+	}
+	return release, nil
+}
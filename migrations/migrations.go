@@ -0,0 +1,286 @@
+// Package migrations версионные миграции схемы БД для dbwrap.DBSQL.
+//
+// Файлы миграций читаются из fs.FS (можно встроить через go:embed) и именуются
+// "<версия>_<имя>[.<драйвер>][.up|.down].sql":
+//
+//	001_init.sql              up и down в одном файле, разделы "-- +up" и "-- +down"
+//	002_users.up.sql          только up
+//	002_users.down.sql        только down
+//	003_index.sqlserver.sql   вариант для драйвера, заменяет общий файл версии
+//
+// Миграции на Go добавляются через WithGoMigration.
+// Применённые версии записываются в таблицу schema_migrations.
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mpuzanov/dbwrap"
+)
+
+// Ошибки миграций.
+var (
+	ErrFileName = errors.New("неверное имя файла миграции")
+	ErrNoDown   = errors.New("нет миграции down")
+	ErrLocked   = errors.New("миграции выполняются другим процессом")
+)
+
+// DefaultTable таблица применённых версий по умолчанию.
+const DefaultTable = "schema_migrations"
+
+// DB запросы миграции на Go: *dbwrap.Tx транзакции миграции, для драйверов без транзакционного DDL (mysql) -
+// *dbwrap.DBSQL. Close не входит в интерфейс, соединения БД закрывает владелец DBSQL.
+type DB interface {
+	Dialect() dbwrap.Dialect
+	ExecContext(ctx context.Context, query string, args ...any) (int64, error)
+	NamedExecContext(ctx context.Context, query string, arg any) (int64, error)
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+	NamedSelectContext(ctx context.Context, dest any, query string, arg any) error
+	SelectMapsContext(ctx context.Context, query string, args ...any) ([]map[string]any, error)
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	NamedGetContext(ctx context.Context, dest any, query string, arg any) error
+	GetMapContext(ctx context.Context, query string, args ...any) (map[string]any, error)
+}
+
+// Func миграция на Go, запросы db выполняются в транзакции миграции.
+type Func func(ctx context.Context, db DB) error
+
+// Migration версия схемы БД.
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string // SQL применения
+	Down     string // SQL отмены
+	UpFile   string // файл SQL применения
+	DownFile string // файл SQL отмены
+	UpFunc   Func   // миграция на Go вместо SQL применения
+	DownFunc Func   // миграция на Go вместо SQL отмены
+}
+
+// Status состояние версии схемы.
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Missing   bool // версия применена, но файла миграции нет
+}
+
+// Migrator выполнение миграций схемы БД.
+type Migrator struct {
+	db          *dbwrap.DBSQL
+	fsys        fs.FS
+	dir         string
+	table       string
+	lockTimeout time.Duration
+	funcs       []*Migration
+}
+
+// Option параметр Migrator.
+type Option func(*Migrator)
+
+// WithTable имя таблицы применённых версий вместо schema_migrations.
+func WithTable(name string) Option {
+	return func(m *Migrator) {
+		m.table = name
+	}
+}
+
+// WithDir каталог файлов миграций в fs.FS, по умолчанию корень.
+func WithDir(dir string) Option {
+	return func(m *Migrator) {
+		m.dir = dir
+	}
+}
+
+// WithLockTimeout максимальное ожидание блокировки миграций другим процессом (по умолчанию 1 минута).
+func WithLockTimeout(d time.Duration) Option {
+	return func(m *Migrator) {
+		m.lockTimeout = d
+	}
+}
+
+// WithGoMigration миграция на Go с версией version, выполняется по порядку версий вместе с файлами SQL.
+// down может быть nil, если отмена миграции не предусмотрена.
+//
+// migrations.WithGoMigration(3, "fill_balance", func(ctx context.Context, db migrations.DB) error {...}, nil)
+func WithGoMigration(version int64, name string, up, down Func) Option {
+	return func(m *Migrator) {
+		m.funcs = append(m.funcs, &Migration{Version: version, Name: name, UpFunc: up, DownFunc: down})
+	}
+}
+
+// New создание Migrator для БД db и файлов миграций из fsys.
+//
+// //go:embed sql/*.sql
+// var files embed.FS
+//
+// err := migrations.New(db, files, migrations.WithDir("sql")).Up(ctx)
+func New(db *dbwrap.DBSQL, fsys fs.FS, opts ...Option) *Migrator {
+	m := &Migrator{db: db, fsys: fsys, dir: ".", table: DefaultTable, lockTimeout: time.Minute}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// migrationFile разобранное имя файла миграции.
+type migrationFile struct {
+	version int64
+	name    string
+	driver  string // "" - общий файл
+	dir     string // up, down или "" - оба раздела в одном файле
+}
+
+// parseFileName разбор имени "<версия>_<имя>[.<драйвер>][.up|.down].sql".
+func parseFileName(file string) (migrationFile, error) {
+	var mf migrationFile
+	base, ok := strings.CutSuffix(file, ".sql")
+	if !ok {
+		return mf, fmt.Errorf("%w: %s", ErrFileName, file)
+	}
+	parts := strings.Split(base, ".")
+	if last := parts[len(parts)-1]; len(parts) > 1 && (last == "up" || last == "down") {
+		mf.dir = last
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) > 1 {
+		if _, ok := dbwrap.GetDialect(parts[len(parts)-1]); !ok {
+			return mf, fmt.Errorf("%w: %s, неизвестный драйвер %s", ErrFileName, file, parts[len(parts)-1])
+		}
+		mf.driver = parts[len(parts)-1]
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) != 1 {
+		return mf, fmt.Errorf("%w: %s", ErrFileName, file)
+	}
+	num, name, _ := strings.Cut(parts[0], "_")
+	v, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || v <= 0 {
+		return mf, fmt.Errorf("%w: %s, ожидается номер версии в начале имени", ErrFileName, file)
+	}
+	mf.version, mf.name = v, name
+	return mf, nil
+}
+
+// splitSections разделы "-- +up" и "-- +down" файла, без разделов весь файл - up.
+func splitSections(text string) (up, down string) {
+	var cur *strings.Builder
+	var upB, downB strings.Builder
+	cur = &upB
+	found := false
+	for _, line := range strings.SplitAfter(text, "\n") {
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "-- +up":
+			cur, found = &upB, true
+			continue
+		case "-- +down":
+			cur, found = &downB, true
+			continue
+		}
+		cur.WriteString(line)
+	}
+	if !found {
+		return text, ""
+	}
+	return strings.TrimSpace(upB.String()), strings.TrimSpace(downB.String())
+}
+
+// Migrations миграции для драйвера БД по возрастанию версий.
+// Файл варианта драйвера заменяет общий файл той же версии и направления.
+func (m *Migrator) Migrations() ([]*Migration, error) {
+	entries, err := fs.ReadDir(m.fsys, m.dir)
+	if err != nil {
+		return nil, fmt.Errorf("migrations read dir: %w", err)
+	}
+	driver := m.db.Dialect().Name()
+
+	type source struct {
+		file    string
+		generic bool
+	}
+	byVersion := map[int64]*Migration{}
+	sources := map[int64]map[string]source{} // направление -> выбранный файл
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".sql") {
+			continue
+		}
+		mf, err := parseFileName(e.Name())
+		if err != nil {
+			return nil, err
+		}
+		if mf.driver != "" && mf.driver != driver {
+			continue
+		}
+		data, err := fs.ReadFile(m.fsys, path.Join(m.dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("migrations read file: %w", err)
+		}
+
+		mg, ok := byVersion[mf.version]
+		if !ok {
+			mg = &Migration{Version: mf.version, Name: mf.name}
+			byVersion[mf.version] = mg
+			sources[mf.version] = map[string]source{}
+		}
+		if mg.Name != mf.name {
+			return nil, fmt.Errorf("%w: %s, версия %d уже используется миграцией %s", ErrFileName, e.Name(), mf.version, mg.Name)
+		}
+
+		sections := map[string]string{}
+		switch mf.dir {
+		case "up", "down":
+			sections[mf.dir] = string(data)
+		default:
+			sections["up"], sections["down"] = splitSections(string(data))
+		}
+		for dir, text := range sections {
+			if mf.dir == "" && dir == "down" && text == "" {
+				continue
+			}
+			prev, exists := sources[mf.version][dir]
+			generic := mf.driver == ""
+			switch {
+			case exists && prev.generic == generic:
+				return nil, fmt.Errorf("%w: %s, %s версии %d уже задан в %s", ErrFileName, e.Name(), dir, mf.version, prev.file)
+			case exists && generic:
+				// вариант драйвера уже выбран
+				continue
+			}
+			sources[mf.version][dir] = source{file: e.Name(), generic: generic}
+			if dir == "up" {
+				mg.Up, mg.UpFile = text, e.Name()
+			} else {
+				mg.Down, mg.DownFile = text, e.Name()
+			}
+		}
+	}
+
+	for _, fn := range m.funcs {
+		if fn.Version <= 0 || fn.UpFunc == nil {
+			return nil, fmt.Errorf("%w: миграция на Go версии %d %s без up", ErrFileName, fn.Version, fn.Name)
+		}
+		if mg, ok := byVersion[fn.Version]; ok {
+			return nil, fmt.Errorf("%w: версия %d миграции на Go %s уже используется миграцией %s", ErrFileName, fn.Version, fn.Name, mg.Name)
+		}
+		byVersion[fn.Version] = fn
+	}
+
+	res := make([]*Migration, 0, len(byVersion))
+	for _, mg := range byVersion {
+		if mg.UpFile == "" && mg.UpFunc == nil {
+			return nil, fmt.Errorf("%w: нет миграции up версии %d %s", ErrFileName, mg.Version, mg.Name)
+		}
+		res = append(res, mg)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Version < res[j].Version })
+	return res, nil
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFileName(t *testing.T) {
+	var tests = []struct {
+		file string
+		want migrationFile
+	}{
+		{"001_init.sql", migrationFile{version: 1, name: "init"}},
+		{"002_users.up.sql", migrationFile{version: 2, name: "users", dir: "up"}},
+		{"002_users.down.sql", migrationFile{version: 2, name: "users", dir: "down"}},
+		{"003_index.sqlserver.sql", migrationFile{version: 3, name: "index", driver: "sqlserver"}},
+		{"20240101120000_add_col.postgres.down.sql", migrationFile{version: 20240101120000, name: "add_col", driver: "postgres", dir: "down"}},
+	}
+	for _, test := range tests {
+		got, err := parseFileName(test.file)
+		require.NoError(t, err, test.file)
+		assert.Equal(t, test.want, got, test.file)
+	}
+
+	for _, file := range []string{"init.sql", "001_init.oracle.sql", "001_init.txt", "000_zero.sql", "001_a.b.sql"} {
+		_, err := parseFileName(file)
+		assert.ErrorIs(t, err, ErrFileName, file)
+	}
+}
+
+func TestSplitSections(t *testing.T) {
+	up, down := splitSections("-- +up\nCREATE TABLE t (id int);\n-- +down\nDROP TABLE t;\n")
+	assert.Equal(t, "CREATE TABLE t (id int);", up)
+	assert.Equal(t, "DROP TABLE t;", down)
+
+	up, down = splitSections("CREATE TABLE t (id int);\n")
+	assert.Equal(t, "CREATE TABLE t (id int);\n", up)
+	assert.Empty(t, down)
+}
+
+func TestSplitStatements(t *testing.T) {
+	text := "CREATE TABLE a (id int);\nCREATE TABLE b (id int);\n-- comment\n"
+	assert.Equal(t, []string{text[:len(text)-1]}, splitStatements("postgres", text))
+	assert.Equal(t, []string{"CREATE TABLE a (id int);", "CREATE TABLE b (id int);"}, splitStatements("mysql", text))
+	assert.Equal(t, []string{"CREATE TABLE a (id int)", "CREATE PROCEDURE p AS SELECT 1"},
+		splitStatements("sqlserver", "CREATE TABLE a (id int)\nGO\nCREATE PROCEDURE p AS SELECT 1\ngo -- end\n"))
+}
@@ -0,0 +1,231 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mpuzanov/dbwrap"
+	"go.uber.org/multierr"
+)
+
+// appliedVersion строка таблицы применённых версий.
+type appliedVersion struct {
+	Version   int64     `db:"version"`
+	Name      string    `db:"name"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// Up применение всех новых миграций.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.UpTo(ctx, math.MaxInt64)
+}
+
+// UpTo применение новых миграций с версией не больше version.
+func (m *Migrator) UpTo(ctx context.Context, version int64) error {
+	return m.locked(ctx, func(migrations []*Migration, applied map[int64]appliedVersion) error {
+		for _, mg := range migrations {
+			if mg.Version > version {
+				break
+			}
+			if _, ok := applied[mg.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, mg, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Down отмена последней применённой миграции.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.locked(ctx, func(migrations []*Migration, applied map[int64]appliedVersion) error {
+		last := lastApplied(applied)
+		if last == 0 {
+			return nil
+		}
+		return m.rollback(ctx, migrations, applied, last-1)
+	})
+}
+
+// DownTo отмена применённых миграций с версией больше version, 0 - отмена всех миграций.
+func (m *Migrator) DownTo(ctx context.Context, version int64) error {
+	return m.locked(ctx, func(migrations []*Migration, applied map[int64]appliedVersion) error {
+		return m.rollback(ctx, migrations, applied, version)
+	})
+}
+
+// Version последняя применённая версия, 0 - миграции не применялись.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return 0, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return lastApplied(applied), nil
+}
+
+// Status состояние всех миграций по возрастанию версий,
+// включая применённые версии, для которых нет файлов.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	migrations, err := m.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	if err = m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Status, 0, len(migrations))
+	for _, mg := range migrations {
+		st := Status{Version: mg.Version, Name: mg.Name}
+		if a, ok := applied[mg.Version]; ok {
+			st.Applied, st.AppliedAt = true, a.AppliedAt
+			delete(applied, mg.Version)
+		}
+		res = append(res, st)
+	}
+	for _, a := range applied {
+		res = append(res, Status{Version: a.Version, Name: a.Name, Applied: true, AppliedAt: a.AppliedAt, Missing: true})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Version < res[j].Version })
+	return res, nil
+}
+
+// locked выполнение fn под блокировкой миграций.
+func (m *Migrator) locked(ctx context.Context, fn func([]*Migration, map[int64]appliedVersion) error) (err error) {
+	migrations, err := m.Migrations()
+	if err != nil {
+		return err
+	}
+	release, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = multierr.Append(err, release())
+	}()
+
+	if err = m.ensureTable(ctx); err != nil {
+		return err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+	return fn(migrations, applied)
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	if _, err := m.db.DBX.ExecContext(ctx, createTableSQL(m.db.Dialect(), m.table)); err != nil {
+		return fmt.Errorf("migrations create table %s: %w", m.table, err)
+	}
+	return nil
+}
+
+// applied применённые версии, читаются с основной БД.
+func (m *Migrator) applied(ctx context.Context) (map[int64]appliedVersion, error) {
+	var rows []appliedVersion
+	query := fmt.Sprintf("SELECT version, name, applied_at FROM %s", m.db.Dialect().QuoteIdent(m.table))
+	if err := m.db.SelectContext(dbwrap.ForcePrimary(ctx), &rows, query); err != nil {
+		return nil, err
+	}
+	res := make(map[int64]appliedVersion, len(rows))
+	for _, r := range rows {
+		res[r.Version] = r
+	}
+	return res, nil
+}
+
+func lastApplied(applied map[int64]appliedVersion) int64 {
+	var last int64
+	for v := range applied {
+		last = max(last, v)
+	}
+	return last
+}
+
+// rollback отмена применённых версий больше version в порядке убывания.
+func (m *Migrator) rollback(ctx context.Context, migrations []*Migration, applied map[int64]appliedVersion, version int64) error {
+	byVersion := make(map[int64]*Migration, len(migrations))
+	for _, mg := range migrations {
+		byVersion[mg.Version] = mg
+	}
+	versions := make([]int64, 0, len(applied))
+	for v := range applied {
+		if v > version {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+	for _, v := range versions {
+		mg, ok := byVersion[v]
+		if !ok || (mg.DownFile == "" && mg.DownFunc == nil) {
+			return fmt.Errorf("%w: версия %d %s", ErrNoDown, v, applied[v].Name)
+		}
+		if err := m.apply(ctx, mg, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply выполнение миграции и запись версии, для драйверов с транзакционным DDL в одной транзакции.
+func (m *Migrator) apply(ctx context.Context, mg *Migration, up bool) (err error) {
+	text, file, fn := mg.Up, mg.UpFile, mg.UpFunc
+	if !up {
+		text, file, fn = mg.Down, mg.DownFile, mg.DownFunc
+	}
+	if fn != nil {
+		file = "go"
+	}
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("migration %d %s (%s): %w", mg.Version, mg.Name, file, err)
+		}
+	}()
+
+	driver := m.db.Dialect().Name()
+	table := m.db.Dialect().QuoteIdent(m.table)
+	run := func(db DB, e sqlx.ExecerContext) error {
+		if fn != nil {
+			if err := fn(ctx, db); err != nil {
+				return err
+			}
+		} else {
+			for _, stmt := range splitStatements(driver, text) {
+				if _, err := e.ExecContext(ctx, stmt); err != nil {
+					return err
+				}
+			}
+		}
+		var err error
+		if up {
+			_, err = e.ExecContext(ctx, m.db.DBX.Rebind(fmt.Sprintf(
+				"INSERT INTO %s (version, name, applied_at) VALUES (?, ?, ?)", table)), mg.Version, mg.Name, time.Now().UTC())
+		} else {
+			_, err = e.ExecContext(ctx, m.db.DBX.Rebind(fmt.Sprintf(
+				"DELETE FROM %s WHERE version = ?", table)), mg.Version)
+		}
+		return err
+	}
+
+	if !transactionalDDL[driver] {
+		return run(m.db, m.db.DBX)
+	}
+	return m.db.WithTx(ctx, nil, func(tx *dbwrap.Tx) error {
+		return run(tx, tx.Tx())
+	})
+}
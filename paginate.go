@@ -0,0 +1,221 @@
+package dbwrap
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrPageOrderBy ошибка.
+var ErrPageOrderBy = errors.New("для постраничной выборки не заданы колонки сортировки")
+
+// ErrPageCursor ошибка.
+var ErrPageCursor = errors.New("неверный курсор постраничной выборки")
+
+// PageRequest параметры постраничной выборки.
+type PageRequest struct {
+	Limit     int      // размер страницы
+	Offset    int      // смещение от начала выборки (не используется при Keyset)
+	OrderBy   []string // колонки сортировки из результата запроса
+	Desc      bool     // сортировка по убыванию
+	Keyset    bool     // выборка по значениям колонок сортировки (seek) вместо смещения
+	Cursor    string   // курсор следующей страницы из Page.NextCursor
+	WithTotal bool     // подсчитать общее количество строк запроса
+}
+
+// Page сведения о полученной странице.
+type Page struct {
+	Total      int64  // общее количество строк, заполняется при PageRequest.WithTotal
+	HasMore    bool   // есть следующая страница
+	NextOffset int    // смещение следующей страницы
+	NextCursor string // курсор следующей страницы при выборке по ключу
+}
+
+// PaginateContext получаем страницу данных запроса в слайс структур.
+// Запрос оборачивается в подзапрос, поэтому не должен содержать ORDER BY.
+//
+// var users []User
+//
+// page, err := ts.db.PaginateContext(ctx, &users, "select * from users", dbwrap.PageRequest{Limit: 20, OrderBy: []string{"name"}})
+func (d *DBSQL) PaginateContext(ctx context.Context, dest any, query string, req PageRequest, args ...any) (*Page, error) {
+	if len(req.OrderBy) == 0 {
+		return nil, ErrPageOrderBy
+	}
+	if req.Limit <= 0 {
+		return nil, fmt.Errorf("page limit %d: размер страницы должен быть больше нуля", req.Limit)
+	}
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return nil, fmt.Errorf("page dest %T: ожидается указатель на слайс", dest)
+	}
+	slice = slice.Elem()
+
+	dialect := d.Dialect()
+	page := &Page{}
+	if req.WithTotal {
+		countQuery := fmt.Sprintf("SELECT COUNT(*) FROM (%s) AS page_count", query)
+		if err := d.GetContext(ctx, &page.Total, countQuery, args...); err != nil {
+			return nil, err
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "SELECT * FROM (%s) AS page_query", query)
+	pageArgs := append([]any{}, args...)
+	offset := req.Offset
+	if req.Keyset {
+		offset = 0
+		if req.Cursor != "" {
+			values, err := decodeCursor(req.Cursor)
+			if err != nil {
+				return nil, err
+			}
+			if len(values) != len(req.OrderBy) {
+				return nil, ErrPageCursor
+			}
+			var where string
+			where, pageArgs = keysetCondition(dialect, req.OrderBy, values, req.Desc, pageArgs)
+			sb.WriteString(" WHERE " + where)
+		}
+	}
+	sb.WriteString(" ORDER BY ")
+	for i, col := range req.OrderBy {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(dialect.QuoteIdent(col))
+		if req.Desc {
+			sb.WriteString(" DESC")
+		}
+	}
+	// на одну строку больше, чтобы определить наличие следующей страницы
+	sb.WriteString(" " + dialect.LimitOffset(req.Limit+1, offset))
+
+	// страница заменяет прежнее содержимое dest, иначе лишняя строка считается по старым элементам
+	slice.SetLen(0)
+	if err := d.SelectContext(ctx, dest, sb.String(), pageArgs...); err != nil {
+		return nil, err
+	}
+
+	if slice.Len() > req.Limit {
+		page.HasMore = true
+		slice.Set(slice.Slice(0, req.Limit))
+	}
+	if page.HasMore {
+		page.NextOffset = offset + req.Limit
+		if req.Keyset {
+			cursor, err := d.pageCursor(slice.Index(slice.Len()-1), req.OrderBy)
+			if err != nil {
+				return nil, err
+			}
+			page.NextCursor = cursor
+		}
+	}
+	return page, nil
+}
+
+// NamedPaginateContext получаем страницу данных запроса с именованными параметрами.
+func (d *DBSQL) NamedPaginateContext(ctx context.Context, dest any, query string, req PageRequest, arg any) (*Page, error) {
+	nq, args, err := d.bindNamed(query, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.PaginateContext(ctx, dest, nq, req, args...)
+}
+
+// keysetCondition условие (c1 > v1) OR (c1 = v1 AND c2 > v2) ...
+// Сравнение кортежей (c1, c2) > (v1, v2) не поддерживается SQL Server, поэтому условие раскрывается.
+func keysetCondition(dialect Dialect, columns []string, values []any, desc bool, args []any) (string, []any) {
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	var sb strings.Builder
+	sb.WriteString("(")
+	for i := range columns {
+		if i > 0 {
+			sb.WriteString(" OR ")
+		}
+		sb.WriteString("(")
+		for j := 0; j <= i; j++ {
+			if j > 0 {
+				sb.WriteString(" AND ")
+			}
+			cmp := "="
+			if j == i {
+				cmp = op
+			}
+			args = append(args, values[j])
+			fmt.Fprintf(&sb, "%s %s %s", dialect.QuoteIdent(columns[j]), cmp, dialect.Placeholder(len(args)))
+		}
+		sb.WriteString(")")
+	}
+	sb.WriteString(")")
+	return sb.String(), args
+}
+
+// pageCursor курсор из значений колонок сортировки последней строки страницы.
+func (d *DBSQL) pageCursor(row reflect.Value, columns []string) (string, error) {
+	row = reflect.Indirect(row)
+	if row.Kind() != reflect.Struct {
+		return "", fmt.Errorf("page row %s: выборка по ключу поддерживается для структур", row.Type())
+	}
+	fields := d.DBX.Mapper.FieldMap(row)
+	values := make([]any, len(columns))
+	for i, col := range columns {
+		f, ok := fields[col]
+		if !ok {
+			f, ok = fields[strings.ToLower(col)]
+		}
+		if !ok {
+			return "", fmt.Errorf("page cursor: колонка %s отсутствует в %s", col, row.Type())
+		}
+		f = reflect.Indirect(f)
+		if f.IsValid() {
+			values[i] = f.Interface()
+		}
+	}
+	return encodeCursor(values)
+}
+
+func encodeCursor(values []any) (string, error) {
+	for i, v := range values {
+		if t, ok := v.(time.Time); ok {
+			values[i] = t.Format(time.RFC3339Nano)
+		}
+	}
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("page cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(cursor string) ([]any, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPageCursor, err)
+	}
+	dec := json.NewDecoder(strings.NewReader(string(b)))
+	dec.UseNumber()
+	var values []any
+	if err := dec.Decode(&values); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPageCursor, err)
+	}
+	for i, v := range values {
+		if n, ok := v.(json.Number); ok {
+			if iv, err := n.Int64(); err == nil {
+				values[i] = iv
+			} else if fv, err := n.Float64(); err == nil {
+				values[i] = fv
+			}
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,151 @@
+package dbwrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"go.uber.org/multierr"
+)
+
+// Ошибки реестра подключений.
+var (
+	ErrUnknownDB      = errors.New("БД не найдена в реестре")
+	ErrRegistryClosed = errors.New("реестр подключений закрыт")
+)
+
+// Registry именованные подключения к нескольким БД.
+// Подключение открывается при первом обращении через Get.
+//
+// configs, err := dbwrap.LoadConfigs("config/db.yaml", "billing", "app")
+//
+// reg := dbwrap.NewRegistry(configs, dbwrap.WithRetry(5, time.Second))
+//
+// defer reg.Close()
+//
+// db, err := reg.Get("billing")
+type Registry struct {
+	mu      sync.Mutex
+	configs map[string]*Config
+	opts    []Option
+	entries map[string]*registryEntry
+	closed  bool
+}
+
+type registryEntry struct {
+	mu sync.Mutex
+	db *DBSQL
+}
+
+// NewRegistry создание реестра, opts применяются при подключении к каждой БД.
+func NewRegistry(configs map[string]*Config, opts ...Option) *Registry {
+	r := &Registry{
+		configs: maps.Clone(configs),
+		opts:    opts,
+		entries: make(map[string]*registryEntry, len(configs)),
+	}
+	for name := range configs {
+		r.entries[name] = &registryEntry{}
+	}
+	return r
+}
+
+// Names имена БД реестра по алфавиту.
+func (r *Registry) Names() []string {
+	return slices.Sorted(maps.Keys(r.configs))
+}
+
+// Get подключение к БД name, при первом обращении выполняется NewConnect.
+// Ошибка подключения не сохраняется, следующий Get повторяет попытку.
+func (r *Registry) Get(name string) (*DBSQL, error) {
+	r.mu.Lock()
+	e, ok := r.entries[name]
+	closed := r.closed
+	r.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDB, name)
+	}
+	if closed {
+		return nil, ErrRegistryClosed
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.db != nil {
+		return e.db, nil
+	}
+	db, err := NewConnect(r.configs[name], r.opts...)
+	if err != nil {
+		return nil, fmt.Errorf("db %s: %w", name, err)
+	}
+
+	// реестр мог быть закрыт во время подключения
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, multierr.Append(ErrRegistryClosed, db.Close())
+	}
+	e.db = db
+	return db, nil
+}
+
+// opened открытые подключения по именам.
+func (r *Registry) opened() map[string]*DBSQL {
+	res := map[string]*DBSQL{}
+	for name, e := range r.entries {
+		e.mu.Lock()
+		if e.db != nil {
+			res[name] = e.db
+		}
+		e.mu.Unlock()
+	}
+	return res
+}
+
+// Close закрытие всех открытых подключений, ошибки объединяются через multierr.
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	r.mu.Unlock()
+
+	var errs error
+	dbs := r.opened()
+	for _, name := range slices.Sorted(maps.Keys(dbs)) {
+		if err := dbs[name].Close(); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("db %s: %w", name, err))
+		}
+	}
+	return errs
+}
+
+// States состояния всех БД реестра по данным фоновой проверки (WithHealthCheck),
+// для неоткрытых подключений StateUnknown.
+func (r *Registry) States() map[string]HealthState {
+	states := make(map[string]HealthState, len(r.configs))
+	for name := range r.configs {
+		states[name] = StateUnknown
+	}
+	for name, db := range r.opened() {
+		states[name] = db.State()
+	}
+	return states
+}
+
+// Ping проверка доступности открытых подключений, ошибки объединяются через multierr.
+func (r *Registry) Ping(ctx context.Context) error {
+	var errs error
+	dbs := r.opened()
+	for _, name := range slices.Sorted(maps.Keys(dbs)) {
+		if err := dbs[name].DBX.PingContext(ctx); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("db %s: %w", name, err))
+		}
+	}
+	return errs
+}
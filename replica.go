@@ -0,0 +1,189 @@
+package dbwrap
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/multierr"
+)
+
+// Политики выбора реплики для читающих запросов.
+const (
+	ReplicaRoundRobin   = "round-robin"   // по очереди
+	ReplicaLeastLatency = "least-latency" // с наименьшим временем ответа фоновой проверки
+)
+
+var replicaPolicies = []string{ReplicaRoundRobin, ReplicaLeastLatency}
+
+// defaultReplicaCheck период фоновой проверки реплик, если не задан WithHealthCheck.
+const defaultReplicaCheck = 10 * time.Second
+
+// replica подключение к реплике для чтения.
+type replica struct {
+	db     *sqlx.DB
+	health *healthMonitor
+}
+
+type primaryKey struct{}
+
+// ForcePrimary контекст, в котором читающие запросы выполняются на основной БД,
+// например для чтения только что записанных данных.
+//
+// err := db.GetContext(dbwrap.ForcePrimary(ctx), &user, "select * from users where id=?", id)
+func ForcePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func isForcePrimary(ctx context.Context) bool {
+	v, _ := ctx.Value(primaryKey{}).(bool)
+	return v
+}
+
+// replicaConfig конфигурация i-й реплики: параметры основной БД с адресом реплики.
+func (c *Config) replicaConfig(i int) (*Config, error) {
+	r := *c
+	r.Replicas = nil
+	addr := strings.TrimSpace(c.Replicas[i])
+	switch {
+	case c.DSN != "":
+		r.DSN = addr
+	case c.DriverName == "sqlite3":
+		r.Database = addr
+	case c.DriverName == "mysql" && strings.HasPrefix(addr, "/"):
+		r.Socket = addr
+	default:
+		r.Socket = ""
+		if err := r.setHostPort(addr); err != nil {
+			return nil, err
+		}
+	}
+	return &r, nil
+}
+
+// validateReplicas проверка адресов реплик и политики выбора.
+func (c *Config) validateReplicas(add func(field string, value any, err error)) {
+	if c.ReplicaPolicy != "" && !slices.Contains(replicaPolicies, c.ReplicaPolicy) {
+		add("ReplicaPolicy", c.ReplicaPolicy, fmt.Errorf("%w, допустимо: %s", ErrInvalidValue, strings.Join(replicaPolicies, ", ")))
+	}
+	for i, addr := range c.Replicas {
+		if strings.TrimSpace(addr) == "" {
+			add("Replicas", nil, fmt.Errorf("%w: пустой адрес реплики %d", ErrInvalidValue, i+1))
+			continue
+		}
+		if _, err := c.replicaConfig(i); err != nil {
+			add("Replicas", addr, fmt.Errorf("%w: %w", ErrInvalidValue, err))
+		}
+	}
+}
+
+// openReplicas подключение к репликам из cfg.Replicas.
+// Реплики открываются без проверки соединения, недоступная реплика
+// исключается из чтения фоновой проверкой или после ошибки соединения.
+func (d *DBSQL) openReplicas(cfg *Config, o *options) error {
+	interval := o.healthInterval
+	if interval <= 0 {
+		interval = defaultReplicaCheck
+	}
+	d.replicaPolicy = cfg.ReplicaPolicy
+	for i := range cfg.Replicas {
+		rc, err := cfg.replicaConfig(i)
+		if err != nil {
+			return fmt.Errorf("replica %d: %w", i+1, err)
+		}
+		if err = rc.registerTLS(); err != nil {
+			return fmt.Errorf("replica %d: %w", i+1, err)
+		}
+		db, err := sqlx.Open(rc.DriverName, rc.GetDatabaseURL())
+		if err != nil {
+			return fmt.Errorf("replica %d: %w", i+1, err)
+		}
+		rc.setSQLitePool(db.DB)
+
+		var onChange HealthFunc
+		if o.logger != nil {
+			n := i + 1
+			onChange = func(state HealthState, err error) {
+				o.logger.Info("database replica state changed", "driver", rc.DriverName, "replica", n, "state", state.String(), "error", err)
+			}
+		}
+		d.replicas = append(d.replicas, &replica{db: db, health: startHealthMonitor(db.DB, interval, onChange)})
+	}
+	return nil
+}
+
+// closeReplicas остановка проверок и закрытие соединений реплик.
+func (d *DBSQL) closeReplicas() error {
+	var errs error
+	for _, r := range d.replicas {
+		r.health.stop()
+		errs = multierr.Append(errs, r.db.Close())
+	}
+	return errs
+}
+
+// ReplicaStates состояния реплик по данным фоновой проверки, в порядке Config.Replicas.
+func (d *DBSQL) ReplicaStates() []HealthState {
+	states := make([]HealthState, len(d.replicas))
+	for i, r := range d.replicas {
+		states[i] = r.health.State()
+	}
+	return states
+}
+
+// pickReplica выбор исправной реплики, nil - чтение с основной БД.
+func (d *DBSQL) pickReplica(ctx context.Context) *replica {
+	n := len(d.replicas)
+	if n == 0 || isForcePrimary(ctx) {
+		return nil
+	}
+	if d.replicaPolicy == ReplicaLeastLatency {
+		var (
+			best    *replica
+			bestLat time.Duration
+		)
+		for _, r := range d.replicas {
+			if r.health.State() == StateDown {
+				continue
+			}
+			// реплика без успешной проверки выбирается в последнюю очередь
+			lat := r.health.Latency()
+			if lat == 0 {
+				lat = math.MaxInt64
+			}
+			if best == nil || lat < bestLat {
+				best, bestLat = r, lat
+			}
+		}
+		return best
+	}
+	start := int((d.nextReplica.Add(1) - 1) % uint64(n))
+	for i := range n {
+		if r := d.replicas[(start+i)%n]; r.health.State() != StateDown {
+			return r
+		}
+	}
+	return nil
+}
+
+// read выполнение читающего запроса в транзакции, на реплике или основной БД.
+// Реплика с разорванным соединением исключается из чтения до следующей успешной проверки,
+// повтор запроса в retryRead выполняется на другой реплике или основной БД.
+func (d *DBSQL) read(ctx context.Context, fn func(q sqlx.QueryerContext) error) error {
+	if d.tx != nil {
+		return fn(d.tx)
+	}
+	r := d.pickReplica(ctx)
+	if r == nil {
+		return fn(d.DBX)
+	}
+	err := fn(r.db)
+	if isBadConn(err) {
+		r.health.markDown(err)
+	}
+	return err
+}
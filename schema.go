@@ -0,0 +1,411 @@
+package dbwrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrSchemaUnsupported ошибка.
+var ErrSchemaUnsupported = errors.New("чтение структуры БД не поддерживается драйвером")
+
+// Schema структура БД: таблицы и представления.
+type Schema struct {
+	Driver string // драйвер БД, для которой прочитана структура
+	Tables []*Table
+
+	model bool // структура по моделям Go (SchemaFromModels), без индексов и точных типов
+}
+
+// Table таблица или представление.
+type Table struct {
+	Schema      string // схема (dbo, public), для mysql - БД, для sqlite3 пустая
+	Name        string
+	View        bool
+	Columns     []*Column
+	PrimaryKey  []string // колонки первичного ключа по порядку
+	Indexes     []*Index
+	ForeignKeys []*ForeignKey
+}
+
+// Column колонка таблицы.
+type Column struct {
+	Name      string
+	Type      string  // тип в синтаксисе СУБД: varchar(50), numeric(10,2)
+	DataType  string  // тип без размера в нижнем регистре: varchar, numeric
+	Length    int     // длина строковых и двоичных типов, -1 для max
+	Precision int     // точность чисел, дробных секунд времени
+	Scale     int     // количество знаков после запятой
+	Nullable  bool    // допускает NULL
+	Default   *string // выражение значения по умолчанию
+	Identity  bool    // автоинкремент (identity, serial, auto_increment, INTEGER PRIMARY KEY)
+}
+
+// Index индекс таблицы.
+type Index struct {
+	Name    string
+	Columns []string
+	Unique  bool
+	Primary bool // индекс первичного ключа
+}
+
+// ForeignKey внешний ключ.
+type ForeignKey struct {
+	Name       string // для sqlite3 пустое
+	Columns    []string
+	RefSchema  string
+	RefTable   string
+	RefColumns []string
+	OnUpdate   string // NO ACTION, RESTRICT, CASCADE, SET NULL, SET DEFAULT
+	OnDelete   string
+}
+
+// Table поиск таблицы по имени или "схема.имя" без учёта регистра, nil - таблицы нет.
+func (s *Schema) Table(name string) *Table {
+	for _, t := range s.Tables {
+		if strings.EqualFold(t.Name, name) || strings.EqualFold(t.FullName(), name) {
+			return t
+		}
+	}
+	return nil
+}
+
+// FullName имя таблицы со схемой.
+func (t *Table) FullName() string {
+	if t.Schema == "" {
+		return t.Name
+	}
+	return t.Schema + "." + t.Name
+}
+
+// Column поиск колонки по имени без учёта регистра, nil - колонки нет.
+func (t *Table) Column(name string) *Column {
+	for _, c := range t.Columns {
+		if strings.EqualFold(c.Name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// строки запросов структуры БД, общие для всех драйверов
+type (
+	tableRow struct {
+		Schema string `db:"table_schema"`
+		Name   string `db:"table_name"`
+		Type   string `db:"table_type"`
+	}
+	columnRow struct {
+		Schema   string  `db:"table_schema"`
+		Table    string  `db:"table_name"`
+		Name     string  `db:"column_name"`
+		Position int     `db:"position"`
+		Type     string  `db:"column_type"`
+		Nullable bool    `db:"nullable"`
+		Default  *string `db:"column_default"`
+		Identity bool    `db:"is_identity"`
+	}
+	indexRow struct {
+		Schema   string `db:"table_schema"`
+		Table    string `db:"table_name"`
+		Name     string `db:"index_name"`
+		Unique   bool   `db:"is_unique"`
+		Primary  bool   `db:"is_primary"`
+		Column   string `db:"column_name"`
+		Position int    `db:"position"`
+	}
+	fkRow struct {
+		Schema    string `db:"table_schema"`
+		Table     string `db:"table_name"`
+		Name      string `db:"fk_name"`
+		Column    string `db:"column_name"`
+		RefSchema string `db:"ref_schema"`
+		RefTable  string `db:"ref_table"`
+		RefColumn string `db:"ref_column"`
+		Position  int    `db:"position"`
+		OnUpdate  string `db:"on_update"`
+		OnDelete  string `db:"on_delete"`
+	}
+)
+
+// schemaRows результаты запросов структуры БД.
+type schemaRows struct {
+	tables  []tableRow
+	columns []columnRow
+	indexes []indexRow
+	fks     []fkRow
+}
+
+// Schema чтение структуры БД: таблицы, представления, колонки, первичные ключи,
+// индексы и внешние ключи. Поддерживаются sqlserver, postgres, mysql и sqlite3.
+// Системные схемы и служебные таблицы sqlite_* не включаются.
+func (d *DBSQL) Schema(ctx context.Context) (*Schema, error) {
+	var (
+		rows schemaRows
+		err  error
+	)
+	switch driver := d.Dialect().Name(); driver {
+	case "sqlite3":
+		rows, err = d.sqliteSchemaRows(ctx)
+	default:
+		queries, ok := schemaQueries[driver]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrSchemaUnsupported, driver)
+		}
+		err = d.schemaSelect(ctx, &rows, queries)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("schema: %w", err)
+	}
+	s := rows.build()
+	s.Driver = d.Dialect().Name()
+	if s.Driver == "sqlite3" {
+		s.sqliteForeignKeys()
+	}
+	return s, nil
+}
+
+// sqliteForeignKeys внешние ключи sqlite3 без имён, ссылка без колонок указывает на первичный ключ.
+func (s *Schema) sqliteForeignKeys() {
+	for _, t := range s.Tables {
+		for _, fk := range t.ForeignKeys {
+			fk.Name = ""
+			ref := s.Table(fk.RefTable)
+			for i, col := range fk.RefColumns {
+				if col == "" && ref != nil && i < len(ref.PrimaryKey) {
+					fk.RefColumns[i] = ref.PrimaryKey[i]
+				}
+			}
+		}
+	}
+}
+
+func (d *DBSQL) schemaSelect(ctx context.Context, rows *schemaRows, q [4]string) error {
+	if err := d.SelectContext(ctx, &rows.tables, q[0]); err != nil {
+		return err
+	}
+	if err := d.SelectContext(ctx, &rows.columns, q[1]); err != nil {
+		return err
+	}
+	if err := d.SelectContext(ctx, &rows.indexes, q[2]); err != nil {
+		return err
+	}
+	return d.SelectContext(ctx, &rows.fks, q[3])
+}
+
+// build сборка Schema из строк запросов.
+func (r schemaRows) build() *Schema {
+	s := &Schema{}
+	byName := map[[2]string]*Table{}
+	for _, tr := range r.tables {
+		t := &Table{Schema: tr.Schema, Name: tr.Name, View: strings.Contains(strings.ToUpper(tr.Type), "VIEW")}
+		s.Tables = append(s.Tables, t)
+		byName[[2]string{tr.Schema, tr.Name}] = t
+	}
+
+	sort.SliceStable(r.columns, func(i, j int) bool { return r.columns[i].Position < r.columns[j].Position })
+	for _, cr := range r.columns {
+		t, ok := byName[[2]string{cr.Schema, cr.Table}]
+		if !ok {
+			continue
+		}
+		c := &Column{Name: cr.Name, Type: cr.Type, Nullable: cr.Nullable, Default: cr.Default, Identity: cr.Identity}
+		c.DataType, c.Length, c.Precision, c.Scale = parseColumnType(cr.Type)
+		t.Columns = append(t.Columns, c)
+	}
+
+	sort.SliceStable(r.indexes, func(i, j int) bool { return r.indexes[i].Position < r.indexes[j].Position })
+	indexes := map[[3]string]*Index{}
+	for _, ir := range r.indexes {
+		t, ok := byName[[2]string{ir.Schema, ir.Table}]
+		if !ok {
+			continue
+		}
+		key := [3]string{ir.Schema, ir.Table, ir.Name}
+		idx, ok := indexes[key]
+		if !ok {
+			idx = &Index{Name: ir.Name, Unique: ir.Unique || ir.Primary, Primary: ir.Primary}
+			indexes[key] = idx
+			t.Indexes = append(t.Indexes, idx)
+		}
+		idx.Columns = append(idx.Columns, ir.Column)
+		if ir.Primary {
+			t.PrimaryKey = append(t.PrimaryKey, ir.Column)
+		}
+	}
+
+	sort.SliceStable(r.fks, func(i, j int) bool { return r.fks[i].Position < r.fks[j].Position })
+	fks := map[[3]string]*ForeignKey{}
+	for _, fr := range r.fks {
+		t, ok := byName[[2]string{fr.Schema, fr.Table}]
+		if !ok {
+			continue
+		}
+		key := [3]string{fr.Schema, fr.Table, fr.Name}
+		fk, ok := fks[key]
+		if !ok {
+			fk = &ForeignKey{Name: fr.Name, RefSchema: fr.RefSchema, RefTable: fr.RefTable,
+				OnUpdate: referentialAction(fr.OnUpdate), OnDelete: referentialAction(fr.OnDelete)}
+			fks[key] = fk
+			t.ForeignKeys = append(t.ForeignKeys, fk)
+		}
+		fk.Columns = append(fk.Columns, fr.Column)
+		fk.RefColumns = append(fk.RefColumns, fr.RefColumn)
+	}
+
+	for _, t := range s.Tables {
+		sort.Slice(t.Indexes, func(i, j int) bool { return t.Indexes[i].Name < t.Indexes[j].Name })
+		sort.Slice(t.ForeignKeys, func(i, j int) bool { return t.ForeignKeys[i].Name < t.ForeignKeys[j].Name })
+	}
+	sort.Slice(s.Tables, func(i, j int) bool { return s.Tables[i].FullName() < s.Tables[j].FullName() })
+	return s
+}
+
+// referentialAction действие внешнего ключа в общем виде.
+func referentialAction(s string) string {
+	switch s {
+	case "a", "":
+		return "NO ACTION"
+	case "r":
+		return "RESTRICT"
+	case "c":
+		return "CASCADE"
+	case "n":
+		return "SET NULL"
+	case "d":
+		return "SET DEFAULT"
+	}
+	return strings.ToUpper(strings.ReplaceAll(s, "_", " "))
+}
+
+// parseColumnType разбор типа "varchar(50)", "numeric(10,2)", "int(11) unsigned".
+func parseColumnType(typ string) (dataType string, length, precision, scale int) {
+	open := strings.IndexByte(typ, '(')
+	closing := strings.IndexByte(typ, ')')
+	if open < 0 || closing < open {
+		return strings.ToLower(strings.TrimSpace(typ)), 0, 0, 0
+	}
+	dataType = strings.ToLower(strings.Join(strings.Fields(typ[:open]+" "+typ[closing+1:]), " "))
+	params := strings.Split(typ[open+1:closing], ",")
+	num := func(s string) int {
+		s = strings.TrimSpace(s)
+		if strings.EqualFold(s, "max") {
+			return -1
+		}
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	switch {
+	case len(params) == 2:
+		precision, scale = num(params[0]), num(params[1])
+	case strings.Contains(dataType, "dec") || strings.Contains(dataType, "numeric") ||
+		strings.Contains(dataType, "time") || strings.Contains(dataType, "float") || strings.Contains(dataType, "real"):
+		precision = num(params[0])
+	default:
+		length = num(params[0])
+	}
+	return dataType, length, precision, scale
+}
+
+// sqliteSchemaRows структура БД sqlite3 из sqlite_master и PRAGMA.
+func (d *DBSQL) sqliteSchemaRows(ctx context.Context) (schemaRows, error) {
+	var rows schemaRows
+	err := d.SelectContext(ctx, &rows.tables, `SELECT '' AS table_schema, name AS table_name, type AS table_type
+FROM sqlite_master WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		return rows, err
+	}
+
+	for _, t := range rows.tables {
+		name := strings.ReplaceAll(t.Name, "'", "''")
+		var cols []struct {
+			CID     int     `db:"cid"`
+			Name    string  `db:"name"`
+			Type    string  `db:"type"`
+			NotNull bool    `db:"notnull"`
+			Default *string `db:"dflt_value"`
+			PK      int     `db:"pk"`
+		}
+		if err = d.SelectContext(ctx, &cols, fmt.Sprintf("PRAGMA table_info('%s')", name)); err != nil {
+			return rows, err
+		}
+		pkCount := 0
+		for _, c := range cols {
+			if c.PK > 0 {
+				pkCount++
+			}
+		}
+		for _, c := range cols {
+			rows.columns = append(rows.columns, columnRow{
+				Table: t.Name, Name: c.Name, Position: c.CID + 1, Type: c.Type,
+				// колонка INTEGER PRIMARY KEY - псевдоним rowid с автоинкрементом
+				Nullable: !c.NotNull && c.PK == 0, Default: c.Default,
+				Identity: c.PK > 0 && pkCount == 1 && strings.EqualFold(c.Type, "INTEGER"),
+			})
+			if c.PK > 0 {
+				rows.indexes = append(rows.indexes, indexRow{
+					Table: t.Name, Name: "PRIMARY", Primary: true, Column: c.Name, Position: c.PK,
+				})
+			}
+		}
+
+		var list []struct {
+			Name   string `db:"name"`
+			Unique bool   `db:"unique"`
+			Origin string `db:"origin"`
+		}
+		if err = d.SelectContext(ctx, &list, fmt.Sprintf("SELECT name, \"unique\", origin FROM pragma_index_list('%s')", name)); err != nil {
+			return rows, err
+		}
+		for _, idx := range list {
+			// индекс первичного ключа уже добавлен по table_info
+			if idx.Origin == "pk" {
+				continue
+			}
+			var info []struct {
+				SeqNo int     `db:"seqno"`
+				Name  *string `db:"name"`
+			}
+			query := fmt.Sprintf("SELECT seqno, name FROM pragma_index_info('%s')", strings.ReplaceAll(idx.Name, "'", "''"))
+			if err = d.SelectContext(ctx, &info, query); err != nil {
+				return rows, err
+			}
+			for _, col := range info {
+				if col.Name == nil {
+					continue
+				}
+				rows.indexes = append(rows.indexes, indexRow{
+					Table: t.Name, Name: idx.Name, Unique: idx.Unique, Column: *col.Name, Position: col.SeqNo + 1,
+				})
+			}
+		}
+
+		var fks []struct {
+			ID       int     `db:"id"`
+			Seq      int     `db:"seq"`
+			Table    string  `db:"table"`
+			From     string  `db:"from"`
+			To       *string `db:"to"`
+			OnUpdate string  `db:"on_update"`
+			OnDelete string  `db:"on_delete"`
+		}
+		query := fmt.Sprintf("SELECT id, seq, \"table\", \"from\", \"to\", on_update, on_delete FROM pragma_foreign_key_list('%s')", name)
+		if err = d.SelectContext(ctx, &fks, query); err != nil {
+			return rows, err
+		}
+		for _, fk := range fks {
+			to := ""
+			if fk.To != nil {
+				to = *fk.To
+			}
+			rows.fks = append(rows.fks, fkRow{
+				Table: t.Name, Name: strconv.Itoa(fk.ID), Column: fk.From, RefTable: fk.Table, RefColumn: to,
+				Position: fk.Seq, OnUpdate: fk.OnUpdate, OnDelete: fk.OnDelete,
+			})
+		}
+	}
+	return rows, nil
+}
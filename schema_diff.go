@@ -0,0 +1,415 @@
+package dbwrap
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SchemaDiff различия структуры сравниваемой БД (target) относительно эталона (source).
+type SchemaDiff struct {
+	MissingTables []*Table     // есть в эталоне, нет в сравниваемой БД
+	ExtraTables   []*Table     // есть в сравниваемой БД, нет в эталоне (не заполняется для моделей)
+	Tables        []*TableDiff // таблицы с различиями
+
+	source, target *Schema
+}
+
+// TableDiff различия таблицы.
+type TableDiff struct {
+	Source, Target *Table
+	MissingColumns []*Column
+	ExtraColumns   []*Column
+	Columns        []*ColumnDiff
+	MissingIndexes []*Index // индексы сравниваются по колонкам и уникальности, имена не учитываются
+	ExtraIndexes   []*Index
+}
+
+// ColumnDiff различия колонки.
+type ColumnDiff struct {
+	Source, Target *Column
+	Type           bool // различается тип
+	Nullable       bool // различается допустимость NULL
+}
+
+// CompareDB сравнение структуры БД target с эталоном source, в том числе разных СУБД.
+func CompareDB(ctx context.Context, source, target *DBSQL) (*SchemaDiff, error) {
+	src, err := source.Schema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := target.Schema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return CompareSchemas(src, dst), nil
+}
+
+// CompareModels сравнение структуры БД со структурами Go с тегами db (SchemaFromModels).
+// Проверяются только таблицы моделей.
+func (d *DBSQL) CompareModels(ctx context.Context, models ...any) (*SchemaDiff, error) {
+	src, err := SchemaFromModels(d.Dialect().Name(), models...)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := d.Schema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return CompareSchemas(src, dst), nil
+}
+
+// CompareSchemas сравнение структуры target с эталоном source.
+// Таблицы и колонки сопоставляются по именам без учёта регистра, для разных СУБД
+// таблицы сопоставляются без схемы, а типы колонок - по виду (строка, целое, дата ...).
+func CompareSchemas(source, target *Schema) *SchemaDiff {
+	diff := &SchemaDiff{source: source, target: target}
+	matched := map[*Table]bool{}
+	for _, st := range source.Tables {
+		tt := diff.matchTable(st)
+		if tt == nil {
+			diff.MissingTables = append(diff.MissingTables, st)
+			continue
+		}
+		matched[tt] = true
+		if td := diff.compareTable(st, tt); td != nil {
+			diff.Tables = append(diff.Tables, td)
+		}
+	}
+	if !source.model {
+		for _, tt := range target.Tables {
+			if !matched[tt] {
+				diff.ExtraTables = append(diff.ExtraTables, tt)
+			}
+		}
+	}
+	return diff
+}
+
+// Empty структуры не различаются.
+func (diff *SchemaDiff) Empty() bool {
+	return len(diff.MissingTables) == 0 && len(diff.ExtraTables) == 0 && len(diff.Tables) == 0
+}
+
+func (diff *SchemaDiff) sameDriver() bool {
+	return diff.source.Driver == diff.target.Driver && !diff.source.model
+}
+
+func (diff *SchemaDiff) matchTable(st *Table) *Table {
+	if st.Schema != "" && diff.source.Driver == diff.target.Driver {
+		return diff.target.Table(st.FullName())
+	}
+	return diff.target.Table(st.Name)
+}
+
+func (diff *SchemaDiff) compareTable(st, tt *Table) *TableDiff {
+	td := &TableDiff{Source: st, Target: tt}
+	for _, sc := range st.Columns {
+		tc := tt.Column(sc.Name)
+		if tc == nil {
+			td.MissingColumns = append(td.MissingColumns, sc)
+			continue
+		}
+		if cd := diff.compareColumn(sc, tc); cd != nil {
+			td.Columns = append(td.Columns, cd)
+		}
+	}
+	for _, tc := range tt.Columns {
+		if st.Column(tc.Name) == nil {
+			td.ExtraColumns = append(td.ExtraColumns, tc)
+		}
+	}
+	if !diff.source.model {
+		td.MissingIndexes = missingIndexes(st.Indexes, tt.Indexes)
+		td.ExtraIndexes = missingIndexes(tt.Indexes, st.Indexes)
+	}
+
+	if len(td.MissingColumns) == 0 && len(td.ExtraColumns) == 0 && len(td.Columns) == 0 &&
+		len(td.MissingIndexes) == 0 && len(td.ExtraIndexes) == 0 {
+		return nil
+	}
+	return td
+}
+
+func (diff *SchemaDiff) compareColumn(sc, tc *Column) *ColumnDiff {
+	cd := &ColumnDiff{Source: sc, Target: tc}
+	srcKind, dstKind := kindOf(diff.source.Driver, sc), kindOf(diff.target.Driver, tc)
+	if diff.sameDriver() {
+		cd.Type = normalizeType(sc.Type) != normalizeType(tc.Type)
+	} else {
+		cd.Type = !compatibleKinds(srcKind, dstKind, diff.source.model) ||
+			srcKind == kindString && !diff.source.model && sc.Length != tc.Length
+	}
+	// []byte и any модели не определяют NULL
+	cd.Nullable = sc.Nullable != tc.Nullable &&
+		!(diff.source.model && (srcKind == kindBytes || srcKind == kindUnknown))
+	if !cd.Type && !cd.Nullable {
+		return nil
+	}
+	return cd
+}
+
+func normalizeType(typ string) string {
+	return strings.ToLower(strings.Join(strings.Fields(typ), ""))
+}
+
+// missingIndexes индексы из a, которых нет в b.
+func missingIndexes(a, b []*Index) []*Index {
+	var res []*Index
+	for _, ia := range a {
+		found := false
+		for _, ib := range b {
+			if sameIndex(ia, ib) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res = append(res, ia)
+		}
+	}
+	return res
+}
+
+func sameIndex(a, b *Index) bool {
+	if a.Unique != b.Unique || a.Primary != b.Primary || len(a.Columns) != len(b.Columns) {
+		return false
+	}
+	for i := range a.Columns {
+		if !strings.EqualFold(a.Columns[i], b.Columns[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// String отчёт о различиях: "-" отсутствует, "+" лишнее, "~" различается.
+func (diff *SchemaDiff) String() string {
+	if diff.Empty() {
+		return "различий нет\n"
+	}
+	var sb strings.Builder
+	for _, t := range diff.MissingTables {
+		fmt.Fprintf(&sb, "- таблица %s отсутствует\n", t.FullName())
+	}
+	for _, t := range diff.ExtraTables {
+		fmt.Fprintf(&sb, "+ лишняя таблица %s\n", t.FullName())
+	}
+	for _, td := range diff.Tables {
+		fmt.Fprintf(&sb, "~ таблица %s\n", td.Target.FullName())
+		for _, c := range td.MissingColumns {
+			fmt.Fprintf(&sb, "  - колонка %s %s отсутствует\n", c.Name, columnTypeNull(c))
+		}
+		for _, c := range td.ExtraColumns {
+			fmt.Fprintf(&sb, "  + лишняя колонка %s %s\n", c.Name, columnTypeNull(c))
+		}
+		for _, cd := range td.Columns {
+			if cd.Type {
+				fmt.Fprintf(&sb, "  ~ колонка %s: тип %s, ожидается %s\n", cd.Target.Name, cd.Target.Type, typeOrAny(cd.Source))
+			}
+			if cd.Nullable {
+				fmt.Fprintf(&sb, "  ~ колонка %s: %s, ожидается %s\n", cd.Target.Name, nullText(cd.Target.Nullable), nullText(cd.Source.Nullable))
+			}
+		}
+		for _, idx := range td.MissingIndexes {
+			fmt.Fprintf(&sb, "  - индекс %s отсутствует\n", indexText(idx))
+		}
+		for _, idx := range td.ExtraIndexes {
+			fmt.Fprintf(&sb, "  + лишний индекс %s\n", indexText(idx))
+		}
+	}
+	return sb.String()
+}
+
+func columnTypeNull(c *Column) string {
+	return typeOrAny(c) + " " + nullText(c.Nullable)
+}
+
+func typeOrAny(c *Column) string {
+	if c.Type == "" {
+		return "<любой>"
+	}
+	return c.Type
+}
+
+func nullText(nullable bool) string {
+	if nullable {
+		return "NULL"
+	}
+	return "NOT NULL"
+}
+
+func indexText(idx *Index) string {
+	var attrs string
+	switch {
+	case idx.Primary:
+		attrs = " PRIMARY KEY"
+	case idx.Unique:
+		attrs = " UNIQUE"
+	}
+	return fmt.Sprintf("%s (%s)%s", idx.Name, strings.Join(idx.Columns, ", "), attrs)
+}
+
+// DDL запросы изменения сравниваемой БД для приведения к эталону в синтаксисе её диалекта d.
+// Запросы - предложения для проверки перед выполнением, в том числе удаление лишних
+// таблиц, колонок и индексов. Изменения, которые диалект не поддерживает (изменение колонки sqlite3),
+// выводятся комментариями.
+func (diff *SchemaDiff) DDL(d Dialect) []string {
+	var creates, alters, drops []string
+	for _, t := range diff.MissingTables {
+		creates = append(creates, diff.createTable(d, t)...)
+	}
+	for _, td := range diff.Tables {
+		table := d.QuoteIdent(td.Target.FullName())
+		for _, c := range td.MissingColumns {
+			add := "ADD COLUMN"
+			if d.Name() == "sqlserver" {
+				add = "ADD"
+			}
+			alters = append(alters, fmt.Sprintf("ALTER TABLE %s %s %s", table, add, diff.columnDef(d, c)))
+		}
+		for _, cd := range td.Columns {
+			alters = append(alters, diff.alterColumn(d, td.Target, cd)...)
+		}
+		for _, idx := range td.ExtraIndexes {
+			drops = append(drops, dropIndex(d, td.Target, idx))
+		}
+		for _, idx := range td.MissingIndexes {
+			alters = append(alters, createIndex(d, td.Target, idx))
+		}
+		for _, c := range td.ExtraColumns {
+			drops = append(drops, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, d.QuoteIdent(c.Name)))
+		}
+	}
+	for _, t := range diff.ExtraTables {
+		drops = append(drops, "DROP TABLE "+d.QuoteIdent(t.FullName()))
+	}
+	return append(append(creates, alters...), drops...)
+}
+
+// createTable создание таблицы эталона в сравниваемой БД с индексами.
+func (diff *SchemaDiff) createTable(d Dialect, t *Table) []string {
+	target := &Table{Schema: t.Schema, Name: t.Name}
+	if !diff.sameDriver() {
+		target.Schema = ""
+	}
+	var defs []string
+	inlinePK := false
+	for _, c := range t.Columns {
+		def := diff.columnDef(d, c)
+		// INTEGER PRIMARY KEY - автоинкремент sqlite3
+		if c.Identity && d.Name() == "sqlite3" && len(t.PrimaryKey) == 1 && strings.EqualFold(t.PrimaryKey[0], c.Name) {
+			def = d.QuoteIdent(c.Name) + " INTEGER PRIMARY KEY"
+			inlinePK = true
+		}
+		defs = append(defs, def)
+	}
+	if len(t.PrimaryKey) > 0 && !inlinePK {
+		defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", quoteList(d, t.PrimaryKey)))
+	}
+	queries := []string{fmt.Sprintf("CREATE TABLE %s (\n    %s\n)", d.QuoteIdent(target.FullName()), strings.Join(defs, ",\n    "))}
+	for _, idx := range t.Indexes {
+		if !idx.Primary {
+			queries = append(queries, createIndex(d, target, idx))
+		}
+	}
+	return queries
+}
+
+// columnDef определение колонки эталона в синтаксисе сравниваемой БД.
+func (diff *SchemaDiff) columnDef(d Dialect, c *Column) string {
+	def := d.QuoteIdent(c.Name) + " " + diff.targetType(d, c)
+	if c.Identity {
+		switch d.Name() {
+		case "sqlserver":
+			def += " IDENTITY(1,1)"
+		case "postgres":
+			def += " GENERATED BY DEFAULT AS IDENTITY"
+		case "mysql":
+			def += " AUTO_INCREMENT"
+		}
+	}
+	if !c.Nullable {
+		def += " NOT NULL"
+	}
+	// выражения значений по умолчанию переносятся только в ту же СУБД
+	if c.Default != nil && diff.sameDriver() {
+		def += " DEFAULT " + *c.Default
+	}
+	return def
+}
+
+func (diff *SchemaDiff) targetType(d Dialect, c *Column) string {
+	if diff.source.model {
+		return typeOrText(d, c)
+	}
+	return sqlType(d.Name(), diff.source.Driver, c)
+}
+
+// typeOrText тип колонки модели, для полей any - строка.
+func typeOrText(d Dialect, c *Column) string {
+	if c.Type != "" {
+		return c.Type
+	}
+	return kindType(d.Name(), kindString, c)
+}
+
+func (diff *SchemaDiff) alterColumn(d Dialect, t *Table, cd *ColumnDiff) []string {
+	table, col := d.QuoteIdent(t.FullName()), d.QuoteIdent(cd.Target.Name)
+	typ := cd.Target.Type
+	if cd.Type {
+		typ = diff.targetType(d, cd.Source)
+	}
+	switch d.Name() {
+	case "sqlserver":
+		return []string{fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s %s", table, col, typ, nullText(cd.Source.Nullable))}
+	case "mysql":
+		return []string{fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s %s", table, col, typ, nullText(cd.Source.Nullable))}
+	case "sqlite3":
+		return []string{fmt.Sprintf("-- %s.%s %s %s: sqlite3 не изменяет колонки, требуется пересоздание таблицы",
+			t.FullName(), cd.Target.Name, typ, nullText(cd.Source.Nullable))}
+	}
+	var queries []string
+	if cd.Type {
+		queries = append(queries, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s", table, col, typ))
+	}
+	if cd.Nullable {
+		action := "SET NOT NULL"
+		if cd.Source.Nullable {
+			action = "DROP NOT NULL"
+		}
+		queries = append(queries, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s", table, col, action))
+	}
+	return queries
+}
+
+func createIndex(d Dialect, t *Table, idx *Index) string {
+	table := d.QuoteIdent(t.FullName())
+	if idx.Primary {
+		return fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (%s)", table, quoteList(d, idx.Columns))
+	}
+	unique := ""
+	if idx.Unique {
+		unique = "UNIQUE "
+	}
+	name := idx.Name
+	if name == "" {
+		name = t.Name + "_" + strings.Join(idx.Columns, "_")
+	}
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)", unique, d.QuoteIdent(name), table, quoteList(d, idx.Columns))
+}
+
+func dropIndex(d Dialect, t *Table, idx *Index) string {
+	table := d.QuoteIdent(t.FullName())
+	switch {
+	case idx.Primary && d.Name() == "mysql":
+		return fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY", table)
+	case idx.Primary:
+		return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", table, d.QuoteIdent(idx.Name))
+	case d.Name() == "sqlserver" || d.Name() == "mysql":
+		return fmt.Sprintf("DROP INDEX %s ON %s", d.QuoteIdent(idx.Name), table)
+	case t.Schema != "":
+		return "DROP INDEX " + d.QuoteIdent(t.Schema+"."+idx.Name)
+	}
+	return "DROP INDEX " + d.QuoteIdent(idx.Name)
+}
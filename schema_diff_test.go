@@ -0,0 +1,171 @@
+package dbwrap
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLType(t *testing.T) {
+	var tests = []struct {
+		from, to string
+		column   Column
+		want     string
+	}{
+		{"sqlserver", "postgres", Column{Type: "bit", DataType: "bit"}, "boolean"},
+		{"sqlserver", "postgres", Column{Type: "uniqueidentifier", DataType: "uniqueidentifier"}, "uuid"},
+		{"sqlserver", "postgres", Column{Type: "datetime", DataType: "datetime"}, "timestamp"},
+		{"sqlserver", "postgres", Column{Type: "nvarchar(50)", DataType: "nvarchar", Length: 50}, "varchar(50)"},
+		{"sqlserver", "postgres", Column{Type: "nvarchar(max)", DataType: "nvarchar", Length: -1}, "text"},
+		{"sqlserver", "mysql", Column{Type: "decimal(18,2)", DataType: "decimal", Precision: 18, Scale: 2}, "decimal(18,2)"},
+		{"sqlserver", "sqlite3", Column{Type: "timestamp", DataType: "timestamp"}, "blob"},
+		{"postgres", "sqlserver", Column{Type: "text", DataType: "text"}, "nvarchar(max)"},
+		{"postgres", "sqlserver", Column{Type: "boolean", DataType: "boolean"}, "bit"},
+		{"postgres", "sqlserver", Column{Type: "timestamp with time zone", DataType: "timestamp with time zone"}, "datetimeoffset"},
+		{"mysql", "postgres", Column{Type: "tinyint(1)", DataType: "tinyint", Length: 1}, "boolean"},
+		{"sqlite3", "postgres", Column{Type: "INTEGER", DataType: "integer"}, "bigint"},
+		{"postgres", "postgres", Column{Type: "character varying(20)", DataType: "character varying", Length: 20}, "character varying(20)"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, sqlType(test.to, test.from, &test.column), test.from+" "+test.column.Type+" -> "+test.to)
+	}
+}
+
+type modelBase struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type flatPerson struct {
+	modelBase
+	LastName string `db:"last_name"`
+	Salary   *float64
+	Note     sql.NullString `db:"note"`
+	Photo    []byte         `db:"photo"`
+	Skip     string         `db:"-"`
+	internal string
+}
+
+type account struct {
+	Login string `db:"login"`
+}
+
+func (account) TableName() string { return "auth.accounts" }
+
+func TestSchemaFromModels(t *testing.T) {
+	s, err := SchemaFromModels("sqlserver", flatPerson{}, &account{})
+	require.NoError(t, err)
+	require.Len(t, s.Tables, 2)
+
+	person := s.Tables[0]
+	assert.Equal(t, "flat_person", person.Name)
+	names := []string{}
+	for _, c := range person.Columns {
+		names = append(names, c.Name+" "+columnTypeNull(c))
+	}
+	assert.Equal(t, []string{"id bigint NOT NULL", "created_at datetime2 NOT NULL", "last_name nvarchar(max) NOT NULL",
+		"salary float NULL", "note nvarchar(max) NULL", "photo varbinary(max) NOT NULL"}, names)
+
+	assert.Equal(t, "auth", s.Tables[1].Schema)
+	assert.Equal(t, "accounts", s.Tables[1].Name)
+
+	_, err = SchemaFromModels("sqlserver", "people")
+	assert.Error(t, err)
+
+	assert.Equal(t, "flat_person", snakeCase("FlatPerson"))
+	assert.Equal(t, "person_id", snakeCase("PersonID"))
+	assert.Equal(t, "http_server", snakeCase("HTTPServer"))
+}
+
+func TestCompareSchemas(t *testing.T) {
+	str := func(s string) *string { return &s }
+	source := &Schema{Driver: "sqlserver", Tables: []*Table{
+		{Schema: "dbo", Name: "people", PrimaryKey: []string{"id"},
+			Columns: []*Column{
+				{Name: "id", Type: "int", DataType: "int", Identity: true},
+				{Name: "last_name", Type: "nvarchar(100)", DataType: "nvarchar", Length: 100},
+				{Name: "is_owner", Type: "bit", DataType: "bit", Default: str("((0))")},
+				{Name: "birthdate", Type: "date", DataType: "date", Nullable: true},
+			},
+			Indexes: []*Index{
+				{Name: "PK_people", Columns: []string{"id"}, Unique: true, Primary: true},
+				{Name: "IX_people_last_name", Columns: []string{"last_name"}},
+			}},
+		{Schema: "dbo", Name: "flats", PrimaryKey: []string{"id"},
+			Columns: []*Column{{Name: "id", Type: "uniqueidentifier", DataType: "uniqueidentifier"}},
+			Indexes: []*Index{{Name: "PK_flats", Columns: []string{"id"}, Unique: true, Primary: true}}},
+	}}
+	target := &Schema{Driver: "postgres", Tables: []*Table{
+		{Schema: "public", Name: "people", PrimaryKey: []string{"id"},
+			Columns: []*Column{
+				{Name: "id", Type: "integer", DataType: "integer", Identity: true},
+				{Name: "last_name", Type: "character varying(50)", DataType: "character varying", Length: 50},
+				{Name: "is_owner", Type: "boolean", DataType: "boolean", Nullable: true},
+				{Name: "email", Type: "text", DataType: "text", Nullable: true},
+			},
+			Indexes: []*Index{
+				{Name: "people_pkey", Columns: []string{"id"}, Unique: true, Primary: true},
+				{Name: "people_email", Columns: []string{"email"}, Unique: true},
+			}},
+		{Schema: "public", Name: "audit"},
+	}}
+
+	diff := CompareSchemas(source, target)
+	require.False(t, diff.Empty())
+	assert.Equal(t, `- таблица dbo.flats отсутствует
++ лишняя таблица public.audit
+~ таблица public.people
+  - колонка birthdate date NULL отсутствует
+  + лишняя колонка email text NULL
+  ~ колонка last_name: тип character varying(50), ожидается nvarchar(100)
+  ~ колонка is_owner: NULL, ожидается NOT NULL
+  - индекс IX_people_last_name (last_name) отсутствует
+  + лишний индекс people_email (email) UNIQUE
+`, diff.String())
+
+	assert.Equal(t, []string{
+		"CREATE TABLE \"flats\" (\n    \"id\" uuid NOT NULL,\n    PRIMARY KEY (\"id\")\n)",
+		`ALTER TABLE "public"."people" ADD COLUMN "birthdate" date`,
+		`ALTER TABLE "public"."people" ALTER COLUMN "last_name" TYPE varchar(100)`,
+		`ALTER TABLE "public"."people" ALTER COLUMN "is_owner" SET NOT NULL`,
+		`CREATE INDEX "IX_people_last_name" ON "public"."people" ("last_name")`,
+		`DROP INDEX "public"."people_email"`,
+		`ALTER TABLE "public"."people" DROP COLUMN "email"`,
+		`DROP TABLE "public"."audit"`,
+	}, diff.DDL(dialectFor("postgres")))
+
+	// обратное сравнение с SQL Server
+	reverse := CompareSchemas(target, source)
+	assert.Contains(t, reverse.DDL(dialectFor("sqlserver")), "DROP TABLE [dbo].[flats]")
+	assert.Contains(t, reverse.DDL(dialectFor("sqlserver")), "ALTER TABLE [dbo].[people] ALTER COLUMN [last_name] nvarchar(50) NOT NULL")
+
+	assert.True(t, CompareSchemas(source, source).Empty())
+	assert.Equal(t, "различий нет\n", CompareSchemas(source, source).String())
+}
+
+func TestCompareModels(t *testing.T) {
+	source, err := SchemaFromModels("postgres", flatPerson{})
+	require.NoError(t, err)
+	target := &Schema{Driver: "postgres", Tables: []*Table{
+		{Schema: "public", Name: "flat_person", Columns: []*Column{
+			{Name: "id", Type: "integer", DataType: "integer"},
+			{Name: "created_at", Type: "timestamp with time zone", DataType: "timestamp with time zone"},
+			{Name: "last_name", Type: "character varying(50)", DataType: "character varying", Length: 50},
+			{Name: "salary", Type: "numeric(10,2)", DataType: "numeric", Precision: 10, Scale: 2, Nullable: true},
+			{Name: "note", Type: "integer", DataType: "integer", Nullable: true},
+			{Name: "photo", Type: "bytea", DataType: "bytea", Nullable: true},
+		}},
+		{Schema: "public", Name: "audit"},
+	}}
+
+	diff := CompareSchemas(source, target)
+	assert.Empty(t, diff.ExtraTables)
+	require.Len(t, diff.Tables, 1)
+	require.Len(t, diff.Tables[0].Columns, 1)
+	assert.Equal(t, "note", diff.Tables[0].Columns[0].Target.Name)
+	assert.True(t, diff.Tables[0].Columns[0].Type)
+	assert.Equal(t, []string{`ALTER TABLE "public"."flat_person" ALTER COLUMN "note" TYPE text`}, diff.DDL(dialectFor("postgres")))
+}
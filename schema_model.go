@@ -0,0 +1,155 @@
+package dbwrap
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// TableNamer модель с именем таблицы БД, по умолчанию имя типа в snake_case.
+type TableNamer interface {
+	TableName() string
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+
+	nullKinds = map[reflect.Type]columnKind{
+		reflect.TypeOf(sql.NullString{}):  kindString,
+		reflect.TypeOf(sql.NullInt64{}):   kindBigInt,
+		reflect.TypeOf(sql.NullInt32{}):   kindInt,
+		reflect.TypeOf(sql.NullInt16{}):   kindInt,
+		reflect.TypeOf(sql.NullByte{}):    kindInt,
+		reflect.TypeOf(sql.NullFloat64{}): kindFloat,
+		reflect.TypeOf(sql.NullBool{}):    kindBool,
+		reflect.TypeOf(sql.NullTime{}):    kindTimestamp,
+	}
+)
+
+// SchemaFromModels структура БД по структурам Go с тегами db для сравнения с БД (CompareSchemas).
+// Имя таблицы - TableName() модели или имя типа в snake_case (FlatPerson -> flat_person),
+// колонки - поля с тегом db или имя поля в нижнем регистре, как в sqlx.
+// Указатели и sql.Null* - колонки с NULL. Типы колонок в синтаксисе драйвера driver.
+// Индексы и первичные ключи моделями не описываются.
+func SchemaFromModels(driver string, models ...any) (*Schema, error) {
+	s := &Schema{Driver: driver, model: true}
+	for _, m := range models {
+		t := reflect.TypeOf(m)
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("модель %T: ожидается структура", m)
+		}
+
+		name := snakeCase(t.Name())
+		if namer, ok := m.(TableNamer); ok {
+			name = namer.TableName()
+		} else if namer, ok := reflect.New(t).Interface().(TableNamer); ok {
+			name = namer.TableName()
+		}
+		table := &Table{Name: name}
+		if i := strings.LastIndexByte(name, '.'); i >= 0 {
+			table.Schema, table.Name = name[:i], name[i+1:]
+		}
+		modelColumns(driver, t, table)
+		s.Tables = append(s.Tables, table)
+	}
+	return s, nil
+}
+
+// modelColumns колонки по полям структуры t, встроенные структуры раскрываются.
+func modelColumns(driver string, t reflect.Type, table *Table) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("db"), ",")[0]
+		if tag == "-" {
+			continue
+		}
+		ft := f.Type
+		if f.Anonymous && tag == "" {
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && ft != timeType && !reflect.PointerTo(ft).Implements(scannerType) {
+				modelColumns(driver, ft, table)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		kind, nullable, ok := modelKind(ft)
+		if !ok {
+			continue
+		}
+		if tag == "" {
+			tag = strings.ToLower(f.Name)
+		}
+		c := &Column{Name: tag, Nullable: nullable}
+		if kind != kindUnknown {
+			c.Type = kindType(driver, kind, c)
+			c.DataType, c.Length, c.Precision, c.Scale = parseColumnType(c.Type)
+		}
+		table.Columns = append(table.Columns, c)
+	}
+}
+
+// modelKind вид типа колонки по типу поля, ok=false - поле не является колонкой.
+func modelKind(t reflect.Type) (kind columnKind, nullable, ok bool) {
+	if t.Kind() == reflect.Ptr {
+		kind, _, ok = modelKind(t.Elem())
+		return kind, true, ok
+	}
+	if k, isNull := nullKinds[t]; isNull {
+		return k, true, true
+	}
+	switch {
+	case t == timeType:
+		return kindTimestamp, false, true
+	case t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8 && t.Len() == 16: // uuid.UUID, mssql.UniqueIdentifier
+		return kindUUID, false, true
+	case reflect.PointerTo(t).Implements(scannerType):
+		return kindUnknown, false, true
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return kindBool, false, true
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return kindInt, false, true
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return kindBigInt, false, true
+	case reflect.Float32, reflect.Float64:
+		return kindFloat, false, true
+	case reflect.String:
+		return kindString, false, true
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return kindBytes, false, true
+		}
+	case reflect.Interface:
+		return kindUnknown, false, true
+	}
+	return kindUnknown, false, false
+}
+
+// snakeCase имя в snake_case: FlatPerson -> flat_person, PersonID -> person_id.
+func snakeCase(name string) string {
+	r := []rune(name)
+	var sb strings.Builder
+	for i, c := range r {
+		if unicode.IsUpper(c) {
+			if i > 0 && (unicode.IsLower(r[i-1]) || unicode.IsDigit(r[i-1]) ||
+				i+1 < len(r) && unicode.IsLower(r[i+1]) && unicode.IsUpper(r[i-1])) {
+				sb.WriteByte('_')
+			}
+			c = unicode.ToLower(c)
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
@@ -0,0 +1,121 @@
+package dbwrap
+
+// schemaQueries запросы структуры БД: таблицы, колонки, индексы, внешние ключи.
+var schemaQueries = map[string][4]string{
+	"postgres": {
+		`SELECT table_schema, table_name, table_type FROM information_schema.tables
+WHERE table_schema NOT IN ('pg_catalog', 'information_schema') ORDER BY 1, 2`,
+
+		`SELECT n.nspname AS table_schema, c.relname AS table_name, a.attname AS column_name, a.attnum AS position,
+	format_type(a.atttypid, a.atttypmod) AS column_type, NOT a.attnotnull AS nullable,
+	pg_get_expr(d.adbin, d.adrelid) AS column_default,
+	(a.attidentity <> '' OR COALESCE(pg_get_expr(d.adbin, d.adrelid), '') LIKE 'nextval(%') AS is_identity
+FROM pg_attribute a
+JOIN pg_class c ON c.oid = a.attrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+WHERE a.attnum > 0 AND NOT a.attisdropped AND c.relkind IN ('r', 'v', 'p', 'm')
+	AND n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname NOT LIKE 'pg_toast%'
+ORDER BY 1, 2, 4`,
+
+		`SELECT n.nspname AS table_schema, t.relname AS table_name, i.relname AS index_name,
+	ix.indisunique AS is_unique, ix.indisprimary AS is_primary, a.attname AS column_name, k.ord AS position
+FROM pg_index ix
+JOIN pg_class t ON t.oid = ix.indrelid
+JOIN pg_class i ON i.oid = ix.indexrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+CROSS JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord)
+JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+WHERE n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname NOT LIKE 'pg_toast%'
+ORDER BY 1, 2, 3, 7`,
+
+		`SELECT n.nspname AS table_schema, c.relname AS table_name, con.conname AS fk_name, a.attname AS column_name,
+	rn.nspname AS ref_schema, rc.relname AS ref_table, ra.attname AS ref_column, k.ord AS position,
+	con.confupdtype::text AS on_update, con.confdeltype::text AS on_delete
+FROM pg_constraint con
+JOIN pg_class c ON c.oid = con.conrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+JOIN pg_class rc ON rc.oid = con.confrelid
+JOIN pg_namespace rn ON rn.oid = rc.relnamespace
+CROSS JOIN LATERAL unnest(con.conkey, con.confkey) WITH ORDINALITY AS k(attnum, refnum, ord)
+JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+JOIN pg_attribute ra ON ra.attrelid = con.confrelid AND ra.attnum = k.refnum
+WHERE con.contype = 'f'
+ORDER BY 1, 2, 3, 8`,
+	},
+
+	"mysql": {
+		`SELECT table_schema AS table_schema, table_name AS table_name, table_type AS table_type
+FROM information_schema.tables WHERE table_schema = DATABASE() ORDER BY 2`,
+
+		`SELECT table_schema AS table_schema, table_name AS table_name, column_name AS column_name,
+	ordinal_position AS position, column_type AS column_type, is_nullable = 'YES' AS nullable,
+	column_default AS column_default, extra LIKE '%auto_increment%' AS is_identity
+FROM information_schema.columns WHERE table_schema = DATABASE() ORDER BY 2, 4`,
+
+		`SELECT table_schema AS table_schema, table_name AS table_name, index_name AS index_name,
+	non_unique = 0 AS is_unique, index_name = 'PRIMARY' AS is_primary, column_name AS column_name,
+	seq_in_index AS position
+FROM information_schema.statistics WHERE table_schema = DATABASE() AND column_name IS NOT NULL ORDER BY 2, 3, 7`,
+
+		`SELECT k.table_schema AS table_schema, k.table_name AS table_name, k.constraint_name AS fk_name,
+	k.column_name AS column_name, k.referenced_table_schema AS ref_schema, k.referenced_table_name AS ref_table,
+	k.referenced_column_name AS ref_column, k.ordinal_position AS position,
+	r.update_rule AS on_update, r.delete_rule AS on_delete
+FROM information_schema.key_column_usage k
+JOIN information_schema.referential_constraints r
+	ON r.constraint_schema = k.constraint_schema AND r.constraint_name = k.constraint_name AND r.table_name = k.table_name
+WHERE k.table_schema = DATABASE() AND k.referenced_table_name IS NOT NULL
+ORDER BY 2, 3, 8`,
+	},
+
+	"sqlserver": {
+		`SELECT s.name AS table_schema, o.name AS table_name,
+	CASE o.type WHEN 'V' THEN 'VIEW' ELSE 'BASE TABLE' END AS table_type
+FROM sys.objects o JOIN sys.schemas s ON s.schema_id = o.schema_id
+WHERE o.type IN ('U', 'V') AND o.is_ms_shipped = 0 ORDER BY 1, 2`,
+
+		`SELECT s.name AS table_schema, o.name AS table_name, c.name AS column_name, c.column_id AS position,
+	CASE
+		WHEN t.name IN ('varchar', 'char', 'varbinary', 'binary')
+			THEN t.name + '(' + CASE WHEN c.max_length = -1 THEN 'max' ELSE CAST(c.max_length AS varchar(10)) END + ')'
+		WHEN t.name IN ('nvarchar', 'nchar')
+			THEN t.name + '(' + CASE WHEN c.max_length = -1 THEN 'max' ELSE CAST(c.max_length / 2 AS varchar(10)) END + ')'
+		WHEN t.name IN ('decimal', 'numeric')
+			THEN t.name + '(' + CAST(c.precision AS varchar(10)) + ',' + CAST(c.scale AS varchar(10)) + ')'
+		WHEN t.name IN ('datetime2', 'time', 'datetimeoffset')
+			THEN t.name + '(' + CAST(c.scale AS varchar(10)) + ')'
+		ELSE t.name
+	END AS column_type,
+	c.is_nullable AS nullable, OBJECT_DEFINITION(c.default_object_id) AS column_default, c.is_identity AS is_identity
+FROM sys.columns c
+JOIN sys.objects o ON o.object_id = c.object_id
+JOIN sys.schemas s ON s.schema_id = o.schema_id
+JOIN sys.types t ON t.user_type_id = c.user_type_id
+WHERE o.type IN ('U', 'V') AND o.is_ms_shipped = 0
+ORDER BY 1, 2, 4`,
+
+		`SELECT s.name AS table_schema, o.name AS table_name, i.name AS index_name, i.is_unique AS is_unique,
+	i.is_primary_key AS is_primary, c.name AS column_name, ic.key_ordinal AS position
+FROM sys.indexes i
+JOIN sys.objects o ON o.object_id = i.object_id
+JOIN sys.schemas s ON s.schema_id = o.schema_id
+JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id AND ic.key_ordinal > 0
+JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+WHERE o.type IN ('U', 'V') AND o.is_ms_shipped = 0 AND i.name IS NOT NULL
+ORDER BY 1, 2, 3, 7`,
+
+		`SELECT s.name AS table_schema, o.name AS table_name, fk.name AS fk_name, c.name AS column_name,
+	rs.name AS ref_schema, ro.name AS ref_table, rc.name AS ref_column, fkc.constraint_column_id AS position,
+	fk.update_referential_action_desc AS on_update, fk.delete_referential_action_desc AS on_delete
+FROM sys.foreign_keys fk
+JOIN sys.objects o ON o.object_id = fk.parent_object_id
+JOIN sys.schemas s ON s.schema_id = o.schema_id
+JOIN sys.objects ro ON ro.object_id = fk.referenced_object_id
+JOIN sys.schemas rs ON rs.schema_id = ro.schema_id
+JOIN sys.foreign_key_columns fkc ON fkc.constraint_object_id = fk.object_id
+JOIN sys.columns c ON c.object_id = fkc.parent_object_id AND c.column_id = fkc.parent_column_id
+JOIN sys.columns rc ON rc.object_id = fkc.referenced_object_id AND rc.column_id = fkc.referenced_column_id
+ORDER BY 1, 2, 3, 8`,
+	},
+}
@@ -0,0 +1,33 @@
+package dbwrap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseColumnType(t *testing.T) {
+	var tests = []struct {
+		typ                      string
+		dataType                 string
+		length, precision, scale int
+	}{
+		{"int", "int", 0, 0, 0},
+		{"VARCHAR(50)", "varchar", 50, 0, 0},
+		{"nvarchar(max)", "nvarchar", -1, 0, 0},
+		{"numeric(10,2)", "numeric", 0, 10, 2},
+		{"decimal(18)", "decimal", 0, 18, 0},
+		{"int(11) unsigned", "int unsigned", 11, 0, 0},
+		{"timestamp(6) without time zone", "timestamp without time zone", 0, 6, 0},
+		{"character varying(255)", "character varying", 255, 0, 0},
+	}
+	for _, test := range tests {
+		dataType, length, precision, scale := parseColumnType(test.typ)
+		assert.Equal(t, test.dataType, dataType, test.typ)
+		assert.Equal(t, []int{test.length, test.precision, test.scale}, []int{length, precision, scale}, test.typ)
+	}
+
+	assert.Equal(t, "SET NULL", referentialAction("n"))
+	assert.Equal(t, "SET NULL", referentialAction("SET_NULL"))
+	assert.Equal(t, "NO ACTION", referentialAction("NO ACTION"))
+}
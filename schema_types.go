@@ -0,0 +1,220 @@
+package dbwrap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// columnKind общий вид типа колонки для сравнения и переноса между СУБД.
+type columnKind string
+
+const (
+	kindUnknown     columnKind = ""
+	kindBool        columnKind = "bool"
+	kindInt         columnKind = "int"
+	kindBigInt      columnKind = "bigint"
+	kindFloat       columnKind = "float"
+	kindDecimal     columnKind = "decimal"
+	kindString      columnKind = "string"
+	kindDate        columnKind = "date"
+	kindTime        columnKind = "time"
+	kindTimestamp   columnKind = "timestamp"
+	kindTimestampTZ columnKind = "timestamptz"
+	kindBytes       columnKind = "bytes"
+	kindUUID        columnKind = "uuid"
+)
+
+// kindOf вид типа колонки c БД драйвера driver.
+func kindOf(driver string, c *Column) columnKind {
+	dataType := c.DataType
+	switch dataType {
+	case "double precision", "character varying", "timestamp with time zone", "timestamp without time zone",
+		"time with time zone", "time without time zone":
+	default:
+		if fields := strings.Fields(dataType); len(fields) > 1 && driver != "sqlite3" {
+			dataType = fields[0] // int unsigned
+		}
+	}
+
+	switch dataType {
+	case "bool", "boolean":
+		return kindBool
+	case "bit":
+		if c.Length <= 1 {
+			return kindBool
+		}
+		return kindBytes
+	case "tinyint":
+		if driver == "mysql" && c.Length == 1 {
+			return kindBool
+		}
+		return kindInt
+	case "smallint", "int", "mediumint", "int2", "int4", "serial", "smallserial", "year":
+		return kindInt
+	case "integer":
+		if driver == "sqlite3" { // 64 бита
+			return kindBigInt
+		}
+		return kindInt
+	case "bigint", "int8", "bigserial":
+		return kindBigInt
+	case "float", "float4", "float8", "real", "double", "double precision":
+		return kindFloat
+	case "numeric", "decimal", "money", "smallmoney", "number":
+		return kindDecimal
+	case "char", "varchar", "nchar", "nvarchar", "text", "ntext", "character", "character varying",
+		"tinytext", "mediumtext", "longtext", "clob", "citext", "xml", "json", "jsonb", "enum", "set":
+		return kindString
+	case "date":
+		return kindDate
+	case "time", "time without time zone", "time with time zone", "timetz":
+		return kindTime
+	case "datetime", "datetime2", "smalldatetime", "timestamp without time zone":
+		return kindTimestamp
+	case "timestamp":
+		if driver == "sqlserver" { // rowversion
+			return kindBytes
+		}
+		return kindTimestamp
+	case "datetimeoffset", "timestamptz", "timestamp with time zone":
+		return kindTimestampTZ
+	case "binary", "varbinary", "image", "rowversion", "bytea", "blob", "tinyblob", "mediumblob", "longblob":
+		return kindBytes
+	case "uniqueidentifier", "uuid":
+		return kindUUID
+	}
+	if driver == "sqlite3" {
+		return sqliteKind(dataType)
+	}
+	return kindUnknown
+}
+
+// sqliteKind вид типа по правилам определения affinity sqlite3.
+func sqliteKind(dataType string) columnKind {
+	switch {
+	case dataType == "":
+		return kindUnknown
+	case strings.Contains(dataType, "int"):
+		return kindBigInt
+	case strings.Contains(dataType, "char"), strings.Contains(dataType, "clob"), strings.Contains(dataType, "text"):
+		return kindString
+	case strings.Contains(dataType, "blob"):
+		return kindBytes
+	case strings.Contains(dataType, "real"), strings.Contains(dataType, "floa"), strings.Contains(dataType, "doub"):
+		return kindFloat
+	case strings.Contains(dataType, "date"), strings.Contains(dataType, "time"):
+		return kindTimestamp
+	case strings.Contains(dataType, "bool"):
+		return kindBool
+	}
+	return kindDecimal
+}
+
+// compatibleKinds совместимость видов типов колонок.
+// loose - сравнение с моделью Go, где int и int64, float64 и numeric не различаются.
+func compatibleKinds(a, b columnKind, loose bool) bool {
+	if a == b || a == kindUnknown || b == kindUnknown {
+		return true
+	}
+	if !loose {
+		return false
+	}
+	group := func(k columnKind) columnKind {
+		switch k {
+		case kindInt:
+			return kindBigInt
+		case kindDecimal:
+			return kindFloat
+		case kindTimestampTZ, kindDate:
+			return kindTimestamp
+		case kindUUID:
+			return kindString
+		}
+		return k
+	}
+	return group(a) == group(b)
+}
+
+// sqlType тип колонки c (драйвера from) в синтаксисе драйвера driver.
+// Для того же драйвера возвращается исходный тип.
+func sqlType(driver, from string, c *Column) string {
+	if driver == from && c.Type != "" {
+		return c.Type
+	}
+	return kindType(driver, kindOf(from, c), c)
+}
+
+// kindType тип вида kind в синтаксисе драйвера driver с размером из c.
+func kindType(driver string, kind columnKind, c *Column) string {
+	switch kind {
+	case kindBool:
+		return orDefault(map[string]string{"sqlserver": "bit", "mysql": "tinyint(1)"}[driver], "boolean")
+	case kindInt:
+		return orDefault(map[string]string{"postgres": "integer", "sqlite3": "integer"}[driver], "int")
+	case kindBigInt:
+		return orDefault(map[string]string{"sqlite3": "integer"}[driver], "bigint")
+	case kindFloat:
+		return orDefault(map[string]string{"sqlserver": "float", "postgres": "double precision", "mysql": "double", "sqlite3": "real"}[driver], "float")
+	case kindDecimal:
+		name := "numeric"
+		if driver == "mysql" {
+			name = "decimal"
+		}
+		if c.Precision > 0 {
+			return fmt.Sprintf("%s(%d,%d)", name, c.Precision, c.Scale)
+		}
+		return name
+	case kindDate:
+		return "date"
+	case kindTime:
+		return "time"
+	case kindTimestamp:
+		return orDefault(map[string]string{"sqlserver": "datetime2", "mysql": "datetime", "sqlite3": "datetime"}[driver], "timestamp")
+	case kindTimestampTZ:
+		return orDefault(map[string]string{"sqlserver": "datetimeoffset", "postgres": "timestamptz", "mysql": "datetime", "sqlite3": "datetime"}[driver], "timestamp")
+	case kindBytes:
+		switch driver {
+		case "sqlserver":
+			return sized("varbinary", c.Length, 8000)
+		case "postgres":
+			return "bytea"
+		case "mysql":
+			if c.Length > 0 {
+				return sized("varbinary", c.Length, 0)
+			}
+			return "longblob"
+		}
+		return "blob"
+	case kindUUID:
+		return orDefault(map[string]string{"sqlserver": "uniqueidentifier", "postgres": "uuid", "sqlite3": "text"}[driver], "char(36)")
+	}
+	// строки и неизвестные типы
+	switch driver {
+	case "sqlserver":
+		return sized("nvarchar", c.Length, 4000)
+	case "mysql":
+		if c.Length > 0 {
+			return sized("varchar", c.Length, 0)
+		}
+		return "longtext"
+	}
+	if c.Length > 0 {
+		return sized("varchar", c.Length, 0)
+	}
+	return "text"
+}
+
+// sized тип с длиной, при длине -1, 0 или больше limit (если задан) - name(max).
+func sized(name string, length, limit int) string {
+	if length > 0 && (limit == 0 || length <= limit) {
+		return fmt.Sprintf("%s(%d)", name, length)
+	}
+	return name + "(max)"
+}
+
+func orDefault(s, def string) string {
+	if s != "" {
+		return s
+	}
+	return def
+}
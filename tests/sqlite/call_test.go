@@ -0,0 +1,72 @@
+package sqlite_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/mpuzanov/dbwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxRows(t *testing.T) {
+	db := openMemoryDB(t)
+	createItems(t, db, 10)
+	ctx := dbwrap.MaxRows(ctxDefault, 5)
+
+	var items []Item
+	err := db.SelectContext(ctx, &items, "SELECT * FROM items")
+	assert.ErrorIs(t, err, dbwrap.ErrMaxRows)
+	require.NoError(t, db.SelectContext(ctx, &items, "SELECT * FROM items WHERE id <= 5"))
+	assert.Len(t, items, 5)
+	assert.Equal(t, "item 05", items[4].Name)
+
+	var ptrs []*Item
+	require.NoError(t, db.SelectContext(ctx, &ptrs, "SELECT * FROM items WHERE id <= 2"))
+	assert.Equal(t, 2, ptrs[1].ID)
+	var ids []int
+	require.NoError(t, db.SelectContext(ctx, &ids, "SELECT id FROM items WHERE id <= 3 ORDER BY id"))
+	assert.Equal(t, []int{1, 2, 3}, ids)
+	// повторное чтение заменяет содержимое dest, как sqlx.SelectContext
+	require.NoError(t, db.SelectContext(ctx, &ids, "SELECT id FROM items WHERE id BETWEEN 6 AND 7 ORDER BY id"))
+	assert.Equal(t, []int{6, 7}, ids)
+
+	_, err = db.SelectMapsContext(ctx, "SELECT * FROM items")
+	assert.ErrorIs(t, err, dbwrap.ErrMaxRows)
+	rows, err := db.SelectMapsContext(ctx, "SELECT * FROM items LIMIT 5")
+	require.NoError(t, err)
+	assert.Len(t, rows, 5)
+}
+
+func TestQueryTimeout(t *testing.T) {
+	db := openMemoryDB(t)
+	createItems(t, db, 3)
+	db.SetTimeoutQuery(0)
+
+	// таймаут подключения 0 секунд, запрос выполняется с QueryTimeout
+	_, err := db.SelectMapsContext(ctxDefault, "SELECT * FROM items")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	_, err = db.SelectMapsContext(dbwrap.QueryTimeout(ctxDefault, time.Minute), "SELECT * FROM items")
+	require.NoError(t, err)
+	_, err = db.ExecContext(dbwrap.NoQueryTimeout(ctxDefault), "DELETE FROM items WHERE id = ?", 1)
+	require.NoError(t, err)
+}
+
+func TestQueryName(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	db, err := dbwrap.NewConnectDSN("sqlite3", ":memory:", dbwrap.WithLogger(logger))
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := dbwrap.QueryName(ctxDefault, "items.count")
+	assert.Equal(t, "items.count", dbwrap.QueryNameFrom(ctx))
+	var n int
+	err = db.GetContext(ctx, &n, "SELECT COUNT(*) FROM items")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "items.count: run query")
+	assert.Contains(t, buf.String(), "name=items.count")
+}
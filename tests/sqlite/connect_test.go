@@ -0,0 +1,48 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mpuzanov/dbwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const badDSN = "file:/nonexistent/dbwrap/test.db?mode=ro"
+
+func TestConnectRetry(t *testing.T) {
+	start := time.Now()
+	_, err := dbwrap.NewConnectDSN("sqlite3", badDSN, dbwrap.WithRetry(3, 10*time.Millisecond))
+	require.Error(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+	assert.Contains(t, err.Error(), "attempt 1:")
+	assert.Contains(t, err.Error(), "attempt 3:")
+
+	_, err = dbwrap.NewConnectDSN("sqlite3", badDSN,
+		dbwrap.WithRetry(100, 10*time.Millisecond), dbwrap.WithMaxWait(50*time.Millisecond))
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "attempt 10:")
+	assert.Contains(t, err.Error(), "max wait 50ms exceeded")
+
+	// срок контекста раньше WithMaxWait
+	for _, opts := range [][]dbwrap.Option{{}, {dbwrap.WithMaxWait(time.Minute)}} {
+		deadline, cancelDeadline := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		opts = append(opts, dbwrap.WithRetry(100, 10*time.Millisecond), dbwrap.WithContext(deadline))
+		_, err = dbwrap.NewConnectDSN("sqlite3", badDSN, opts...)
+		cancelDeadline()
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Contains(t, err.Error(), "context deadline")
+		assert.NotContains(t, err.Error(), "max wait")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = dbwrap.NewConnectDSN("sqlite3", badDSN, dbwrap.WithRetry(5, time.Second), dbwrap.WithContext(ctx))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	db, err := dbwrap.NewConnect(dbwrap.NewConfig("sqlite3"), dbwrap.WithRetry(3, 10*time.Millisecond))
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+}
@@ -0,0 +1,75 @@
+package sqlite_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mpuzanov/dbwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopy(t *testing.T) {
+	src := openMemoryDB(t)
+	dst := openMemoryDB(t)
+	for _, query := range []string{
+		`CREATE TABLE accounts (id INTEGER NOT NULL, active BIT, guid UNIQUEIDENTIFIER, balance DECIMAL(10,2),
+			opened DATETIME, name NVARCHAR(50), photo VARBINARY(100))`,
+		`INSERT INTO accounts VALUES (1, 1, '6f9619ff-8b86-d011-b42d-00c04fc964ff', 10.5, '2024-01-02 03:04:05', 'Иванов', x'0102')`,
+		`INSERT INTO accounts VALUES (2, 0, NULL, NULL, NULL, NULL, NULL)`,
+		`INSERT INTO accounts VALUES (3, 1, NULL, 0.25, '2024-03-04 05:06:07', 'Петров', NULL)`,
+	} {
+		_, err := src.ExecContext(ctxDefault, query)
+		require.NoError(t, err)
+	}
+
+	n, err := dbwrap.Copy(ctxDefault, src, dst, "select * from accounts order by id", "accounts_copy",
+		dbwrap.CopyOptions{CreateTable: true, BatchSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+
+	s, err := dst.Schema(ctxDefault)
+	require.NoError(t, err)
+	table := s.Table("accounts_copy")
+	require.NotNil(t, table)
+	types := map[string]string{}
+	for _, c := range table.Columns {
+		types[c.Name] = strings.ToLower(c.Type)
+	}
+	assert.Equal(t, map[string]string{"id": "integer", "active": "boolean", "guid": "text", "balance": "numeric(10,2)",
+		"opened": "datetime", "name": "varchar(50)", "photo": "blob"}, types)
+
+	var rows []struct {
+		ID      int        `db:"id"`
+		Active  bool       `db:"active"`
+		GUID    *string    `db:"guid"`
+		Balance *float64   `db:"balance"`
+		Opened  *time.Time `db:"opened"`
+		Name    *string    `db:"name"`
+		Photo   []byte     `db:"photo"`
+	}
+	require.NoError(t, dst.SelectContext(ctxDefault, &rows, "select * from accounts_copy order by id"))
+	require.Len(t, rows, 3)
+	assert.True(t, rows[0].Active)
+	assert.False(t, rows[1].Active)
+	assert.Equal(t, "6f9619ff-8b86-d011-b42d-00c04fc964ff", *rows[0].GUID)
+	assert.Equal(t, 10.5, *rows[0].Balance)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), *rows[0].Opened)
+	assert.Equal(t, "Иванов", *rows[0].Name)
+	assert.Equal(t, []byte{1, 2}, rows[0].Photo)
+	assert.Nil(t, rows[1].Balance)
+	assert.Nil(t, rows[1].Opened)
+
+	// в существующую таблицу, колонки по именам
+	n, err = dbwrap.Copy(ctxDefault, src, dst, "select name, id from accounts where id in (?)", "accounts_copy",
+		dbwrap.CopyOptions{CreateTable: true}, []int{1, 3})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	var count int
+	require.NoError(t, dst.GetContext(ctxDefault, &count, "select count(*) from accounts_copy"))
+	assert.Equal(t, 5, count)
+
+	_, err = dbwrap.Copy(ctxDefault, src, dst, "select id, name as title from accounts", "accounts_copy", dbwrap.CopyOptions{})
+	assert.ErrorIs(t, err, dbwrap.ErrImportColumn)
+}
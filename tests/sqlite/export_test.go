@@ -0,0 +1,103 @@
+package sqlite_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/mpuzanov/dbwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createSales(t *testing.T, db *dbwrap.DBSQL) {
+	for _, query := range []string{
+		`CREATE TABLE sales (id INTEGER, customer TEXT, amount NUMERIC(10,2), sold_on DATE, created_at DATETIME, paid BOOLEAN)`,
+		`INSERT INTO sales VALUES (1, 'Иванов, "ИП"', 1000.5, '2024-01-02', '2024-01-02 03:04:05', 1)`,
+		`INSERT INTO sales VALUES (2, NULL, NULL, NULL, NULL, NULL)`,
+	} {
+		_, err := db.ExecContext(ctxDefault, query)
+		require.NoError(t, err)
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	db := openMemoryDB(t)
+	createSales(t, db)
+
+	var buf bytes.Buffer
+	n, err := db.ExportContext(ctxDefault, &buf, "select id, customer, amount, sold_on, created_at from sales order by id",
+		dbwrap.ExportOptions{Format: dbwrap.ExportCSV})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.Equal(t, "id,customer,amount,sold_on,created_at\n"+
+		"1,\"Иванов, \"\"ИП\"\"\",1000.5,2024-01-02,2024-01-02T03:04:05Z\n"+
+		"2,,,,\n", buf.String())
+
+	buf.Reset()
+	_, err = db.NamedExportContext(ctxDefault, &buf, "select amount, created_at, customer from sales where id in (:ids) order by id",
+		dbwrap.ExportOptions{Format: dbwrap.ExportCSV, Comma: ';', Null: "NULL", DecimalSeparator: ",", TimeFormat: "02.01.2006 15:04"},
+		map[string]any{"ids": []int{1, 2}})
+	require.NoError(t, err)
+	assert.Equal(t, "amount;created_at;customer\n"+
+		"1000,5;02.01.2024 03:04;\"Иванов, \"\"ИП\"\"\"\n"+
+		"NULL;NULL;NULL\n", buf.String())
+
+	_, err = db.ExportContext(ctxDefault, &buf, "select 1", dbwrap.ExportOptions{Format: "xml"})
+	assert.ErrorIs(t, err, dbwrap.ErrExportFormat)
+}
+
+func TestExportJSONL(t *testing.T) {
+	db := openMemoryDB(t)
+	createSales(t, db)
+
+	var buf bytes.Buffer
+	n, err := db.ExportContext(ctxDefault, &buf, "select id, customer, amount, sold_on, paid from sales order by id",
+		dbwrap.ExportOptions{Format: dbwrap.ExportJSONL})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.Equal(t, `{"id":1,"customer":"Иванов, \"ИП\"","amount":1000.5,"sold_on":"2024-01-02","paid":true}
+{"id":2,"customer":null,"amount":null,"sold_on":null,"paid":null}
+`, buf.String())
+
+	buf.Reset()
+	_, err = db.ExportContext(ctxDefault, &buf, "select amount from sales where id = ?",
+		dbwrap.ExportOptions{Format: dbwrap.ExportJSONL, DecimalAsString: true}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"amount\":\"1000.5\"}\n", buf.String())
+}
+
+func TestExportXLSX(t *testing.T) {
+	db := openMemoryDB(t)
+	createSales(t, db)
+
+	var buf bytes.Buffer
+	n, err := db.ExportContext(ctxDefault, &buf, "select id, customer, amount, sold_on, created_at, paid from sales order by id",
+		dbwrap.ExportOptions{Format: dbwrap.ExportXLSX, SheetName: "Продажи"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		files[f.Name] = string(data)
+	}
+	assert.Contains(t, files, "[Content_Types].xml")
+	assert.Contains(t, files, "xl/styles.xml")
+	assert.Contains(t, files["xl/workbook.xml"], `<sheet name="Продажи" sheetId="1" r:id="rId1"/>`)
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<row r="1"><c r="A1" t="inlineStr" s="3"><is><t xml:space="preserve">id</t></is></c>`)
+	assert.Contains(t, sheet, `<row r="2"><c r="A2"><v>1</v></c>`+
+		`<c r="B2" t="inlineStr"><is><t xml:space="preserve">Иванов, &#34;ИП&#34;</t></is></c>`+
+		`<c r="C2"><v>1000.5</v></c><c r="D2" s="1"><v>45293</v></c>`+
+		`<c r="E2" s="2"><v>45293.12783564815</v></c><c r="F2" t="b"><v>1</v></c></row>`)
+	assert.Contains(t, sheet, `<row r="3"><c r="A3"><v>2</v></c></row></sheetData></worksheet>`)
+}
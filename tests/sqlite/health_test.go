@@ -0,0 +1,69 @@
+package sqlite_test
+
+import (
+	"database/sql/driver"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mpuzanov/dbwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazyConnect(t *testing.T) {
+	db, err := dbwrap.NewConnectDSN("sqlite3", badDSN, dbwrap.WithLazy())
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.SelectMapsContext(ctxDefault, `select 1`)
+	assert.Error(t, err)
+}
+
+func TestHealthCheck(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		states []dbwrap.HealthState
+	)
+	onChange := func(state dbwrap.HealthState, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		states = append(states, state)
+	}
+
+	db, err := dbwrap.NewConnectDSN("sqlite3", badDSN, dbwrap.WithLazy(), dbwrap.WithHealthCheck(10*time.Millisecond, onChange))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return db.State() == dbwrap.StateDown }, time.Second, 5*time.Millisecond)
+	require.NoError(t, db.Close())
+
+	db, err = dbwrap.NewConnectDSN("sqlite3", ":memory:", dbwrap.WithHealthCheck(10*time.Millisecond, onChange))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return db.State() == dbwrap.StateUp }, time.Second, 5*time.Millisecond)
+	require.NoError(t, db.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []dbwrap.HealthState{dbwrap.StateDown, dbwrap.StateUp}, states)
+}
+
+// badConnOnce возвращает driver.ErrBadConn при первом чтении.
+type badConnOnce struct {
+	failed bool
+}
+
+func (b *badConnOnce) Scan(any) error {
+	if !b.failed {
+		b.failed = true
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func TestRetryReadBadConn(t *testing.T) {
+	db := openMemoryDB(t)
+
+	var v badConnOnce
+	err := db.GetContext(ctxDefault, &v, `select 1`)
+	require.NoError(t, err)
+	assert.True(t, v.failed)
+}
@@ -0,0 +1,164 @@
+package sqlite_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mpuzanov/dbwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createImportTable(t *testing.T, db *dbwrap.DBSQL) {
+	_, err := db.ExecContext(ctxDefault, `CREATE TABLE payments (id INTEGER PRIMARY KEY, customer TEXT NOT NULL,
+		amount NUMERIC(10,2), paid_on DATE, created_at DATETIME, paid BOOLEAN, extra TEXT)`)
+	require.NoError(t, err)
+}
+
+func TestImportCSV(t *testing.T) {
+	db := openMemoryDB(t)
+	createImportTable(t, db)
+
+	data := "id;customer;amount;paid_on;created_at;paid\n" +
+		"1;Иванов;1000,50;2024-01-02;2024-01-02 03:04:05;true\n" +
+		"2;Петров;abc;;;\n" +
+		"3;;;;;\n" + // NOT NULL customer при загрузке отдельной строкой
+		"1;Сидоров;1;;;0\n" + // повтор первичного ключа
+		"4;Кузнецов\n" +
+		"5;Смирнов;;2024-02-03;2024-02-03T10:00:00+03:00;0\n"
+	res, err := db.ImportContext(ctxDefault, strings.NewReader(data), "payments",
+		dbwrap.ImportOptions{Format: dbwrap.ExportCSV, Comma: ';', Null: "", DecimalSeparator: ",", BatchSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), res.Rows)
+	require.Len(t, res.Errors, 4)
+	assert.Equal(t, 3, res.Errors[0].Line)
+	assert.Equal(t, "amount", res.Errors[0].Column)
+	assert.Equal(t, 4, res.Errors[1].Line)
+	assert.Contains(t, res.Errors[1].Error(), "NOT NULL")
+	assert.Equal(t, 5, res.Errors[2].Line)
+	assert.Equal(t, 6, res.Errors[3].Line)
+
+	var rows []struct {
+		ID        int        `db:"id"`
+		Customer  string     `db:"customer"`
+		Amount    *float64   `db:"amount"`
+		PaidOn    *time.Time `db:"paid_on"`
+		CreatedAt *time.Time `db:"created_at"`
+		Paid      bool       `db:"paid"`
+	}
+	require.NoError(t, db.SelectContext(ctxDefault, &rows, "select id, customer, amount, paid_on, created_at, paid from payments order by id"))
+	require.Len(t, rows, 2)
+	assert.Equal(t, "Иванов", rows[0].Customer)
+	assert.Equal(t, 1000.5, *rows[0].Amount)
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), *rows[0].PaidOn)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), *rows[0].CreatedAt)
+	assert.True(t, rows[0].Paid)
+	assert.Nil(t, rows[1].Amount)
+	assert.True(t, rows[1].CreatedAt.Equal(time.Date(2024, 2, 3, 7, 0, 0, 0, time.UTC)))
+
+	_, err = db.ImportContext(ctxDefault, strings.NewReader(data), "payments",
+		dbwrap.ImportOptions{Format: dbwrap.ExportCSV, Comma: ';', MaxErrors: 1})
+	assert.ErrorIs(t, err, dbwrap.ErrImportErrors)
+
+	_, err = db.ImportContext(ctxDefault, strings.NewReader("id,phone\n1,123\n"), "payments",
+		dbwrap.ImportOptions{Format: dbwrap.ExportCSV})
+	assert.ErrorIs(t, err, dbwrap.ErrImportColumn)
+}
+
+func TestImportColumns(t *testing.T) {
+	db := openMemoryDB(t)
+	createImportTable(t, db)
+
+	data := "Код,Клиент,Комментарий\n10,Иванов,не загружается\n11,Петров,\n"
+	res, err := db.ImportContext(ctxDefault, strings.NewReader(data), "payments", dbwrap.ImportOptions{
+		Format:  dbwrap.ExportCSV,
+		Columns: map[string]string{"Код": "id", "Клиент": "customer"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), res.Rows)
+	assert.Empty(t, res.Errors)
+
+	var customers []string
+	require.NoError(t, db.SelectContext(ctxDefault, &customers, "select customer from payments order by id"))
+	assert.Equal(t, []string{"Иванов", "Петров"}, customers)
+
+	_, err = db.ImportContext(ctxDefault, strings.NewReader(data), "payments", dbwrap.ImportOptions{
+		Format:  dbwrap.ExportCSV,
+		Columns: map[string]string{"Телефон": "extra"},
+	})
+	assert.ErrorIs(t, err, dbwrap.ErrImportColumn)
+}
+
+func TestImportJSONL(t *testing.T) {
+	db := openMemoryDB(t)
+	createImportTable(t, db)
+
+	data := `{"id":1,"customer":"Иванов","amount":1000.5,"paid":true,"extra":{"tags":["a","b"]}}
+
+{"id":2,"customer":"Петров","amount":null,"paid":false}
+{"id":"x","customer":"Сидоров"}
+{"id":4,"customer":"Кузнецов","phone":"123"}
+{"id":5,
+`
+	res, err := db.ImportContext(ctxDefault, strings.NewReader(data), "payments", dbwrap.ImportOptions{Format: dbwrap.ExportJSONL})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), res.Rows)
+	require.Len(t, res.Errors, 3)
+	assert.Equal(t, 4, res.Errors[0].Line)
+	assert.Equal(t, "id", res.Errors[0].Column)
+	assert.Equal(t, 5, res.Errors[1].Line)
+	assert.ErrorIs(t, res.Errors[1], dbwrap.ErrImportColumn)
+	assert.Equal(t, 6, res.Errors[2].Line)
+
+	row, err := db.GetMapContext(ctxDefault, "select amount, paid, extra from payments where id = 1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"amount": 1000.5, "paid": true, "extra": `{"tags":["a","b"]}`}, row)
+
+	// поля по первому корректному объекту
+	data = "{\"id\":\n[1]\n{\"id\":10,\"customer\":\"Смирнов\"}\n"
+	res, err = db.ImportContext(ctxDefault, strings.NewReader(data), "payments", dbwrap.ImportOptions{Format: dbwrap.ExportJSONL})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), res.Rows)
+	require.Len(t, res.Errors, 2)
+	assert.Equal(t, 1, res.Errors[0].Line)
+	assert.Equal(t, 2, res.Errors[1].Line)
+
+	res, err = db.ImportContext(ctxDefault, strings.NewReader(data), "payments",
+		dbwrap.ImportOptions{Format: dbwrap.ExportJSONL, MaxErrors: 1})
+	assert.ErrorIs(t, err, dbwrap.ErrImportErrors)
+	assert.Equal(t, int64(0), res.Rows)
+	assert.Len(t, res.Errors, 1)
+
+	res, err = db.ImportContext(ctxDefault, strings.NewReader("{\n"), "payments", dbwrap.ImportOptions{Format: dbwrap.ExportJSONL})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), res.Rows)
+	assert.Len(t, res.Errors, 1)
+}
+
+func TestImportExported(t *testing.T) {
+	db := openMemoryDB(t)
+	createSales(t, db)
+	_, err := db.ExecContext(ctxDefault, `CREATE TABLE sales_copy (id INTEGER, customer TEXT, amount NUMERIC(10,2), sold_on DATE, created_at DATETIME, paid BOOLEAN)`)
+	require.NoError(t, err)
+
+	for _, format := range []dbwrap.ExportFormat{dbwrap.ExportCSV, dbwrap.ExportJSONL} {
+		_, err = db.ExecContext(ctxDefault, "delete from sales_copy")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		_, err = db.ExportContext(ctxDefault, &buf, "select * from sales", dbwrap.ExportOptions{Format: format})
+		require.NoError(t, err)
+		res, err := db.ImportContext(ctxDefault, &buf, "sales_copy", dbwrap.ImportOptions{Format: format})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), res.Rows)
+		assert.Empty(t, res.Errors)
+
+		want, err := db.SelectMapsContext(ctxDefault, "select * from sales order by id")
+		require.NoError(t, err)
+		got, err := db.SelectMapsContext(ctxDefault, "select * from sales_copy order by id")
+		require.NoError(t, err)
+		assert.Equal(t, want, got, format)
+	}
+}
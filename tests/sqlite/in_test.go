@@ -0,0 +1,32 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectIn(t *testing.T) {
+	db := openMemoryDB(t)
+	createItems(t, db, 10)
+
+	var got []Item
+	err := db.SelectContext(ctxDefault, &got, `select * from items where id in (?) and grp <> ? order by id`, []int{2, 4, 5, 9}, "g1")
+	require.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, 4, got[0].ID)
+
+	got = nil
+	err = db.NamedSelectContext(ctxDefault, &got, `select * from items where id in (:Ids) order by id`, map[string]any{"Ids": []int{3, 7}})
+	require.NoError(t, err)
+	assert.Len(t, got, 2)
+
+	count, err := db.NamedExecContext(ctxDefault, `delete from items where name in (:Names)`, map[string]any{"Names": []string{"item 01", "item 10"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	rows, err := db.SelectMapsContext(ctxDefault, `select id from items where id in (?)`, []int64{1, 2, 3})
+	require.NoError(t, err)
+	assert.Len(t, rows, 2)
+}
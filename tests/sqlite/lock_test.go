@@ -0,0 +1,68 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mpuzanov/dbwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLock(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.db")
+	open := func() *dbwrap.DBSQL {
+		cfg := dbwrap.NewConfig("sqlite3").WithDB(file)
+		cfg.SQLite.BusyTimeout = 5000
+		db, err := dbwrap.NewConnect(cfg)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+		return db
+	}
+	db1, db2 := open(), open()
+
+	release, err := db1.Lock(ctxDefault, "report")
+	require.NoError(t, err)
+
+	_, ok, err := db2.TryLock(ctxDefault, "report")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	other, ok, err := db2.TryLock(ctxDefault, "other")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	require.NoError(t, other())
+
+	ctx, cancel := context.WithTimeout(ctxDefault, 100*time.Millisecond)
+	defer cancel()
+	_, err = db2.Lock(ctx, "report")
+	assert.ErrorIs(t, err, dbwrap.ErrLockTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// блокировка освобождается во время ожидания
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = release()
+	}()
+	release2, err := db2.Lock(ctxDefault, "report")
+	require.NoError(t, err)
+	require.NoError(t, release2())
+	require.NoError(t, release2())
+
+	// строка процесса, завершившегося без release
+	_, err = db1.ExecContext(ctxDefault, "INSERT INTO dbwrap_locks (name, locked_at) VALUES (?, ?)",
+		"crashed", time.Now().UTC().Add(-2*time.Minute))
+	require.NoError(t, err)
+	release3, ok, err := db2.TryLock(ctxDefault, "crashed")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	require.NoError(t, release3())
+
+	// ошибки БД не считаются занятой блокировкой
+	canceled, cancelAll := context.WithCancel(ctxDefault)
+	cancelAll()
+	_, _, err = db2.TryLock(canceled, "report")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, dbwrap.ErrLockTimeout)
+}
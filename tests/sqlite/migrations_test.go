@@ -0,0 +1,166 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mpuzanov/dbwrap"
+	"github.com/mpuzanov/dbwrap/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var migrationFiles = fstest.MapFS{
+	"sql/001_init.sql": {Data: []byte(`-- +up
+CREATE TABLE accounts (id INTEGER PRIMARY KEY, name TEXT NOT NULL);
+CREATE INDEX accounts_name ON accounts (name);
+-- +down
+DROP TABLE accounts;
+`)},
+	"sql/001_init.sqlserver.sql": {Data: []byte(`-- +up
+CREATE TABLE accounts (id INT IDENTITY PRIMARY KEY, name NVARCHAR(100) NOT NULL)
+GO
+-- +down
+DROP TABLE accounts
+`)},
+	"sql/002_balance.up.sql":         {Data: []byte("ALTER TABLE accounts ADD COLUMN balance NUMERIC NOT NULL DEFAULT 0;")},
+	"sql/002_balance.sqlite3.up.sql": {Data: []byte("ALTER TABLE accounts ADD COLUMN balance REAL NOT NULL DEFAULT 0;")},
+	"sql/002_balance.down.sql":       {Data: []byte("ALTER TABLE accounts DROP COLUMN balance;")},
+	"sql/003_seed.sql":               {Data: []byte("INSERT INTO accounts (id, name) VALUES (1, 'cash');")},
+	"sql/004_broken.sql":             {Data: []byte("-- +up\nINSERT INTO accounts (id, name) VALUES (2, 'bank');\nINSERT INTO missing VALUES (1);\n-- +down\nSELECT 1;")},
+}
+
+func TestMigrations(t *testing.T) {
+	db, err := dbwrap.NewConnect(dbwrap.NewConfig("sqlite3").WithDB(filepath.Join(t.TempDir(), "app.db")))
+	require.NoError(t, err)
+	defer db.Close()
+
+	m := migrations.New(db, migrationFiles, migrations.WithDir("sql"))
+	list, err := m.Migrations()
+	require.NoError(t, err)
+	require.Len(t, list, 4)
+	assert.Equal(t, "002_balance.sqlite3.up.sql", list[1].UpFile)
+	assert.Equal(t, "002_balance.down.sql", list[1].DownFile)
+	assert.Equal(t, "001_init.sql", list[0].UpFile)
+
+	require.NoError(t, m.UpTo(ctxDefault, 3))
+	version, err := m.Version(ctxDefault)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), version)
+	var balance float64
+	require.NoError(t, db.GetContext(ctxDefault, &balance, "SELECT balance FROM accounts WHERE id = 1"))
+
+	// ошибка миграции отменяет её транзакцию
+	err = m.Up(ctxDefault)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migration 4 broken (004_broken.sql)")
+	var count int
+	require.NoError(t, db.GetContext(ctxDefault, &count, "SELECT COUNT(*) FROM accounts"))
+	assert.Equal(t, 1, count)
+
+	status, err := m.Status(ctxDefault)
+	require.NoError(t, err)
+	require.Len(t, status, 4)
+	assert.True(t, status[2].Applied)
+	assert.False(t, status[2].AppliedAt.IsZero())
+	assert.False(t, status[3].Applied)
+
+	// 003_seed без down
+	err = m.DownTo(ctxDefault, 1)
+	assert.ErrorIs(t, err, migrations.ErrNoDown)
+
+	_, err = db.ExecContext(ctxDefault, "DELETE FROM schema_migrations WHERE version = 3")
+	require.NoError(t, err)
+	require.NoError(t, m.Down(ctxDefault))
+	version, err = m.Version(ctxDefault)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), version)
+	require.NoError(t, m.DownTo(ctxDefault, 0))
+	_, err = db.SelectMapsContext(ctxDefault, "SELECT * FROM accounts")
+	assert.Error(t, err)
+}
+
+func TestMigrationsConcurrent(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.db")
+	files := fstest.MapFS{
+		"001_init.sql": {Data: []byte("CREATE TABLE accounts (id INTEGER PRIMARY KEY);")},
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cfg := dbwrap.NewConfig("sqlite3").WithDB(file)
+			cfg.SQLite.BusyTimeout = 5000
+			db, err := dbwrap.NewConnect(cfg)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer db.Close()
+			errs[i] = migrations.New(db, files).Up(ctxDefault)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+}
+
+func TestGoMigrations(t *testing.T) {
+	db, err := dbwrap.NewConnect(dbwrap.NewConfig("sqlite3").WithDB(filepath.Join(t.TempDir(), "app.db")))
+	require.NoError(t, err)
+	defer db.Close()
+
+	files := fstest.MapFS{
+		"001_init.sql":  {Data: []byte("-- +up\nCREATE TABLE accounts (id INTEGER PRIMARY KEY, name TEXT, code TEXT);\n-- +down\nDROP TABLE accounts;")},
+		"003_index.sql": {Data: []byte("-- +up\nCREATE UNIQUE INDEX accounts_code ON accounts (code);\n-- +down\nDROP INDEX accounts_code;")},
+	}
+	var order []string
+	fill := func(ctx context.Context, db migrations.DB) error {
+		order = append(order, "fill")
+		assert.IsType(t, &dbwrap.Tx{}, db)
+		_, err := db.NamedExecContext(ctx, "INSERT INTO accounts (id, name, code) VALUES (:id, :name, :code)",
+			[]map[string]any{{"id": 1, "name": "cash", "code": "C1"}, {"id": 2, "name": "bank", "code": "B1"}})
+		return err
+	}
+	empty := func(ctx context.Context, db migrations.DB) error {
+		_, err := db.ExecContext(ctx, "DELETE FROM accounts")
+		return err
+	}
+	broken := func(ctx context.Context, db migrations.DB) error {
+		if _, err := db.ExecContext(ctx, "DELETE FROM accounts"); err != nil {
+			return err
+		}
+		return errors.New("backfill failed")
+	}
+
+	m := migrations.New(db, files,
+		migrations.WithGoMigration(2, "fill", fill, empty),
+		migrations.WithGoMigration(4, "broken", broken, nil))
+	err = m.Up(ctxDefault)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migration 4 broken (go): backfill failed")
+	assert.Equal(t, []string{"fill"}, order)
+
+	// изменения неудачной миграции на Go отменены вместе с транзакцией
+	var count int
+	require.NoError(t, db.GetContext(ctxDefault, &count, "SELECT COUNT(*) FROM accounts"))
+	assert.Equal(t, 2, count)
+	version, err := m.Version(ctxDefault)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), version)
+
+	require.NoError(t, m.DownTo(ctxDefault, 1))
+	require.NoError(t, db.GetContext(ctxDefault, &count, "SELECT COUNT(*) FROM accounts"))
+	assert.Equal(t, 0, count)
+
+	_, err = migrations.New(db, files, migrations.WithGoMigration(3, "dup", fill, nil)).Migrations()
+	assert.ErrorIs(t, err, migrations.ErrFileName)
+}
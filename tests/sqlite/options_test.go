@@ -0,0 +1,91 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/mpuzanov/dbwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteOptions(t *testing.T) {
+	ctx := context.Background()
+	cfg := dbwrap.NewConfig("sqlite3").WithDB(filepath.Join(t.TempDir(), "test.db"))
+	cfg.SQLite = dbwrap.SQLiteOptions{JournalMode: "WAL", BusyTimeout: 3000, ForeignKeys: true}
+	db, err := dbwrap.NewConnect(cfg)
+	require.NoError(t, err)
+
+	var mode string
+	require.NoError(t, db.GetContext(ctx, &mode, "PRAGMA journal_mode"))
+	assert.Equal(t, "wal", mode)
+	var n int
+	require.NoError(t, db.GetContext(ctx, &n, "PRAGMA busy_timeout"))
+	assert.Equal(t, 3000, n)
+	require.NoError(t, db.GetContext(ctx, &n, "PRAGMA foreign_keys"))
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 0, db.DBX.Stats().MaxOpenConnections)
+
+	_, err = db.ExecContext(ctx, "CREATE TABLE parent (id INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "CREATE TABLE child (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parent(id))")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "INSERT INTO child (id, parent_id) VALUES (1, 100)")
+	assert.Error(t, err)
+	require.NoError(t, db.Close())
+
+	cfg.SQLite = dbwrap.SQLiteOptions{ReadOnly: true}
+	ro, err := dbwrap.NewConnect(cfg)
+	require.NoError(t, err)
+	defer ro.Close()
+	require.NoError(t, ro.GetContext(ctx, &n, "SELECT COUNT(*) FROM parent"))
+	_, err = ro.ExecContext(ctx, "INSERT INTO parent (id) VALUES (1)")
+	assert.Error(t, err)
+}
+
+func TestSQLiteFilePool(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pool.db")
+	// без параметров SQLite пул файла не ограничивается
+	db, err := dbwrap.NewConnect(dbwrap.NewConfig("sqlite3").WithDB(file))
+	require.NoError(t, err)
+	assert.Equal(t, 0, db.DBX.Stats().MaxOpenConnections)
+	require.NoError(t, db.Close())
+
+	cfg := dbwrap.NewConfig("sqlite3").WithDB(file)
+	cfg.SQLite.JournalMode = "delete"
+	db, err = dbwrap.NewConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	assert.Equal(t, 1, db.DBX.Stats().MaxOpenConnections)
+}
+
+func TestSQLiteMemoryPool(t *testing.T) {
+	ctx := context.Background()
+	db, err := dbwrap.NewConnect(dbwrap.NewConfig("sqlite3"))
+	require.NoError(t, err)
+	defer db.Close()
+	assert.Equal(t, 1, db.DBX.Stats().MaxOpenConnections)
+
+	// общая БД в памяти видна всем соединениям пула
+	cfg := dbwrap.NewConfig("sqlite3").WithDB("shared_pool")
+	cfg.Params = map[string]string{"mode": "memory"}
+	cfg.SQLite.SharedCache = true
+	shared, err := dbwrap.NewConnect(cfg)
+	require.NoError(t, err)
+	defer shared.Close()
+	_, err = shared.ExecContext(ctx, "CREATE TABLE t (id INTEGER)")
+	require.NoError(t, err)
+
+	conns := make([]interface{ Close() error }, 0, 3)
+	for range 3 {
+		conn, err := shared.DBX.Connx(ctx)
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, conn.GetContext(ctx, &n, "SELECT COUNT(*) FROM t"))
+		conns = append(conns, conn)
+	}
+	for _, conn := range conns {
+		require.NoError(t, conn.Close())
+	}
+}
@@ -0,0 +1,87 @@
+package sqlite_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mpuzanov/dbwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Item struct {
+	ID    int    `db:"id"`
+	Group string `db:"grp"`
+	Name  string `db:"name"`
+}
+
+// openMemoryDB БД в памяти с одним соединением, чтобы все запросы видели одни данные.
+func openMemoryDB(t *testing.T) *dbwrap.DBSQL {
+	db, err := dbwrap.NewConnectDSN("sqlite3", ":memory:")
+	require.NoError(t, err)
+	db.DBX.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func createItems(t *testing.T, db *dbwrap.DBSQL, count int) {
+	_, err := db.ExecContext(ctxDefault, `CREATE TABLE items (id int PRIMARY KEY, grp varchar(10), name varchar(50))`)
+	require.NoError(t, err)
+	items := make([]Item, count)
+	for i := range items {
+		items[i] = Item{ID: i + 1, Group: fmt.Sprintf("g%d", i%3), Name: fmt.Sprintf("item %02d", i+1)}
+	}
+	_, err = db.NamedExecContext(ctxDefault, `INSERT INTO items (id, grp, name) VALUES (:id, :grp, :name)`, items)
+	require.NoError(t, err)
+}
+
+func TestPaginateOffset(t *testing.T) {
+	db := openMemoryDB(t)
+	createItems(t, db, 25)
+
+	req := dbwrap.PageRequest{Limit: 10, OrderBy: []string{"id"}, WithTotal: true}
+	var got []Item
+	page, err := db.PaginateContext(ctxDefault, &got, `select * from items`, req)
+	require.NoError(t, err)
+	assert.Len(t, got, 10)
+	assert.Equal(t, int64(25), page.Total)
+	assert.True(t, page.HasMore)
+	assert.Equal(t, 10, page.NextOffset)
+
+	req.Offset = 20
+	page, err = db.NamedPaginateContext(ctxDefault, &got, `select * from items where id > :ID`, req, map[string]any{"ID": 0})
+	require.NoError(t, err)
+	assert.Len(t, got, 5)
+	assert.Equal(t, 21, got[0].ID)
+	assert.False(t, page.HasMore)
+
+	_, err = db.PaginateContext(ctxDefault, &got, `select * from items`, dbwrap.PageRequest{Limit: 10})
+	assert.ErrorIs(t, err, dbwrap.ErrPageOrderBy)
+}
+
+func TestPaginateKeyset(t *testing.T) {
+	db := openMemoryDB(t)
+	createItems(t, db, 25)
+
+	req := dbwrap.PageRequest{Limit: 4, OrderBy: []string{"grp", "id"}, Keyset: true}
+	var ids []int
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 10)
+		var got []Item
+		page, err := db.PaginateContext(ctxDefault, &got, `select * from items where id <= ?`, req, 20)
+		require.NoError(t, err)
+		for _, it := range got {
+			ids = append(ids, it.ID)
+		}
+		if !page.HasMore {
+			break
+		}
+		req.Cursor = page.NextCursor
+	}
+	assert.Len(t, ids, 20)
+	assert.ElementsMatch(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}, ids)
+	assert.Equal(t, []int{1, 4, 7, 10}, ids[:4])
+
+	_, err := db.PaginateContext(ctxDefault, &[]Item{}, `select * from items`, dbwrap.PageRequest{Limit: 4, OrderBy: []string{"id"}, Keyset: true, Cursor: "bad!"})
+	assert.ErrorIs(t, err, dbwrap.ErrPageCursor)
+}
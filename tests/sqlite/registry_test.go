@@ -0,0 +1,46 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/mpuzanov/dbwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	dir := t.TempDir()
+	reg := dbwrap.NewRegistry(map[string]*dbwrap.Config{
+		"app":    dbwrap.NewConfig("sqlite3").WithDB(filepath.Join(dir, "app.db")),
+		"cache":  dbwrap.NewConfig("sqlite3"),
+		"broken": dbwrap.NewConfig("sqlite3").WithDB("/nonexistent/dbwrap/test.db"),
+	})
+	assert.Equal(t, []string{"app", "broken", "cache"}, reg.Names())
+	assert.Equal(t, map[string]dbwrap.HealthState{
+		"app": dbwrap.StateUnknown, "broken": dbwrap.StateUnknown, "cache": dbwrap.StateUnknown,
+	}, reg.States())
+
+	app, err := reg.Get("app")
+	require.NoError(t, err)
+	again, err := reg.Get("app")
+	require.NoError(t, err)
+	assert.Same(t, app, again)
+	_, err = app.ExecContext(context.Background(), "CREATE TABLE t (id INTEGER)")
+	require.NoError(t, err)
+
+	_, err = reg.Get("broken")
+	assert.ErrorIs(t, err, dbwrap.ErrInvalidValue)
+	_, err = reg.Get("billing")
+	assert.ErrorIs(t, err, dbwrap.ErrUnknownDB)
+
+	_, err = reg.Get("cache")
+	require.NoError(t, err)
+	require.NoError(t, reg.Ping(context.Background()))
+
+	require.NoError(t, reg.Close())
+	_, err = reg.Get("app")
+	assert.ErrorIs(t, err, dbwrap.ErrRegistryClosed)
+	assert.Error(t, app.DBX.Ping())
+}
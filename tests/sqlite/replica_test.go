@@ -0,0 +1,73 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mpuzanov/dbwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createNode файл БД с таблицей node, в которой записано имя БД.
+func createNode(t *testing.T, dir, name string) string {
+	t.Helper()
+	file := filepath.Join(dir, name+".db")
+	db, err := dbwrap.NewConnect(dbwrap.NewConfig("sqlite3").WithDB(file))
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.ExecContext(context.Background(), "CREATE TABLE node (name TEXT)")
+	require.NoError(t, err)
+	_, err = db.ExecContext(context.Background(), "INSERT INTO node (name) VALUES (?)", name)
+	require.NoError(t, err)
+	return file
+}
+
+func TestReplicaRouting(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfg := dbwrap.NewConfig("sqlite3").WithDB(createNode(t, dir, "primary"))
+	cfg.Replicas = []string{createNode(t, dir, "replica1"), createNode(t, dir, "replica2")}
+	db, err := dbwrap.NewConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	assert.Len(t, db.ReplicaStates(), 2)
+
+	var names []string
+	for range 4 {
+		var name string
+		require.NoError(t, db.GetContext(ctx, &name, "SELECT name FROM node"))
+		names = append(names, name)
+	}
+	assert.Equal(t, []string{"replica1", "replica2", "replica1", "replica2"}, names)
+
+	m, err := db.GetMapContext(ctx, "SELECT name FROM node")
+	require.NoError(t, err)
+	assert.Contains(t, []any{"replica1", "replica2"}, m["name"])
+
+	// запись и чтение своих данных на основной БД
+	_, err = db.ExecContext(ctx, "UPDATE node SET name = ?", "primary updated")
+	require.NoError(t, err)
+	var rows []string
+	require.NoError(t, db.SelectContext(dbwrap.ForcePrimary(ctx), &rows, "SELECT name FROM node"))
+	assert.Equal(t, []string{"primary updated"}, rows)
+}
+
+func TestReplicaFallback(t *testing.T) {
+	ctx := context.Background()
+	cfg := dbwrap.NewConfig("sqlite3").WithDB(createNode(t, t.TempDir(), "primary"))
+	cfg.Replicas = []string{"/nonexistent/dbwrap/replica.db"}
+	db, err := dbwrap.NewConnect(cfg, dbwrap.WithHealthCheck(10*time.Millisecond, nil))
+	require.NoError(t, err)
+	defer db.Close()
+
+	// недоступная реплика исключается из чтения фоновой проверкой
+	require.Eventually(t, func() bool {
+		return db.ReplicaStates()[0] == dbwrap.StateDown
+	}, time.Second, 10*time.Millisecond)
+	var name string
+	require.NoError(t, db.GetContext(ctx, &name, "SELECT name FROM node"))
+	assert.Equal(t, "primary", name)
+}
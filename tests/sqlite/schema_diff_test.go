@@ -0,0 +1,78 @@
+package sqlite_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mpuzanov/dbwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareDB(t *testing.T) {
+	source, target := openMemoryDB(t), openMemoryDB(t)
+	exec := func(db *dbwrap.DBSQL, queries ...string) {
+		for _, query := range queries {
+			_, err := db.ExecContext(ctxDefault, query)
+			require.NoError(t, err, query)
+		}
+	}
+	exec(source,
+		`CREATE TABLE customers (id INTEGER PRIMARY KEY, name VARCHAR(100) NOT NULL, email TEXT, phone VARCHAR(20))`,
+		`CREATE UNIQUE INDEX customers_email ON customers (email)`,
+		`CREATE TABLE orders (id INTEGER PRIMARY KEY, customer_id INTEGER NOT NULL, amount NUMERIC(10,2) NOT NULL DEFAULT 0)`,
+		`CREATE INDEX orders_customer ON orders (customer_id)`)
+	exec(target,
+		`CREATE TABLE customers (id INTEGER PRIMARY KEY, name VARCHAR(100) NOT NULL, email TEXT, note TEXT)`,
+		`CREATE INDEX customers_name ON customers (name)`,
+		`CREATE TABLE tmp (id INT)`)
+
+	diff, err := dbwrap.CompareDB(ctxDefault, source, target)
+	require.NoError(t, err)
+	assert.Equal(t, `- таблица orders отсутствует
++ лишняя таблица tmp
+~ таблица customers
+  - колонка phone VARCHAR(20) NULL отсутствует
+  + лишняя колонка note TEXT NULL
+  - индекс customers_email (email) UNIQUE отсутствует
+  + лишний индекс customers_name (name)
+`, diff.String())
+
+	queries := diff.DDL(target.Dialect())
+	assert.Contains(t, queries, "CREATE TABLE \"orders\" (\n    \"id\" INTEGER PRIMARY KEY,\n"+
+		"    \"customer_id\" INTEGER NOT NULL,\n    \"amount\" NUMERIC(10,2) NOT NULL DEFAULT 0\n)")
+	exec(target, queries...)
+
+	diff, err = dbwrap.CompareDB(ctxDefault, source, target)
+	require.NoError(t, err)
+	assert.True(t, diff.Empty(), diff.String())
+
+	// изменение колонки sqlite3 только комментарием
+	exec(source, `CREATE TABLE notes (id INT NOT NULL, body TEXT NOT NULL)`)
+	exec(target, `CREATE TABLE notes (id INT NOT NULL, body TEXT)`)
+	diff, err = dbwrap.CompareDB(ctxDefault, source, target)
+	require.NoError(t, err)
+	queries = diff.DDL(target.Dialect())
+	require.Len(t, queries, 1)
+	assert.True(t, strings.HasPrefix(queries[0], "-- notes.body TEXT NOT NULL"), queries[0])
+}
+
+func TestCompareModels(t *testing.T) {
+	db := openMemoryDB(t)
+	_, err := db.ExecContext(ctxDefault, `CREATE TABLE person (last_name VARCHAR(50) NOT NULL, birthdate DATE,
+		salary NUMERIC(10,2), is_owner_flat BOOLEAN NOT NULL, created_at DATETIME NOT NULL, note TEXT)`)
+	require.NoError(t, err)
+
+	diff, err := db.CompareModels(ctxDefault, Person{})
+	require.NoError(t, err)
+	assert.Equal(t, `~ таблица person
+  - колонка email text NOT NULL отсутствует
+  + лишняя колонка note TEXT NULL
+  ~ колонка is_owner_flat: NOT NULL, ожидается NULL
+`, diff.String())
+	assert.Equal(t, []string{
+		`ALTER TABLE "person" ADD COLUMN "email" text NOT NULL`,
+		`-- person.is_owner_flat BOOLEAN NULL: sqlite3 не изменяет колонки, требуется пересоздание таблицы`,
+		`ALTER TABLE "person" DROP COLUMN "note"`,
+	}, diff.DDL(db.Dialect()))
+}
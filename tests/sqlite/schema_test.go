@@ -0,0 +1,70 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/mpuzanov/dbwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema(t *testing.T) {
+	db := openMemoryDB(t)
+	for _, query := range []string{
+		`CREATE TABLE customers (id INTEGER PRIMARY KEY, name VARCHAR(100) NOT NULL, email TEXT, created_at DATETIME DEFAULT CURRENT_TIMESTAMP)`,
+		`CREATE UNIQUE INDEX customers_email ON customers (email)`,
+		`CREATE TABLE orders (
+			customer_id INTEGER NOT NULL REFERENCES customers ON DELETE CASCADE,
+			num INT NOT NULL,
+			amount NUMERIC(10,2) NOT NULL DEFAULT 0,
+			PRIMARY KEY (customer_id, num))`,
+		`CREATE INDEX orders_amount ON orders (amount, num)`,
+		`CREATE VIEW customer_orders AS SELECT c.name, o.amount FROM customers c JOIN orders o ON o.customer_id = c.id`,
+	} {
+		_, err := db.ExecContext(ctxDefault, query)
+		require.NoError(t, err)
+	}
+
+	s, err := db.Schema(ctxDefault)
+	require.NoError(t, err)
+	names := []string{}
+	for _, table := range s.Tables {
+		names = append(names, table.Name)
+	}
+	assert.Equal(t, []string{"customer_orders", "customers", "orders"}, names)
+
+	customers := s.Table("CUSTOMERS")
+	require.NotNil(t, customers)
+	assert.False(t, customers.View)
+	assert.Equal(t, []string{"id"}, customers.PrimaryKey)
+	require.Len(t, customers.Columns, 4)
+	assert.True(t, customers.Columns[0].Identity)
+	assert.Equal(t, &dbwrap.Column{Name: "name", Type: "VARCHAR(100)", DataType: "varchar", Length: 100},
+		customers.Column("name"))
+	assert.True(t, customers.Column("email").Nullable)
+	require.NotNil(t, customers.Column("created_at").Default)
+	assert.Equal(t, "CURRENT_TIMESTAMP", *customers.Column("created_at").Default)
+	assert.Equal(t, []*dbwrap.Index{
+		{Name: "PRIMARY", Columns: []string{"id"}, Unique: true, Primary: true},
+		{Name: "customers_email", Columns: []string{"email"}, Unique: true},
+	}, customers.Indexes)
+
+	orders := s.Table("orders")
+	require.NotNil(t, orders)
+	assert.Equal(t, []string{"customer_id", "num"}, orders.PrimaryKey)
+	assert.False(t, orders.Columns[0].Identity)
+	amount := orders.Column("amount")
+	assert.Equal(t, "numeric", amount.DataType)
+	assert.Equal(t, 10, amount.Precision)
+	assert.Equal(t, 2, amount.Scale)
+	assert.Contains(t, orders.Indexes, &dbwrap.Index{Name: "orders_amount", Columns: []string{"amount", "num"}})
+	assert.Equal(t, []*dbwrap.ForeignKey{{
+		Columns: []string{"customer_id"}, RefTable: "customers", RefColumns: []string{"id"},
+		OnUpdate: "NO ACTION", OnDelete: "CASCADE",
+	}}, orders.ForeignKeys)
+
+	view := s.Table("customer_orders")
+	require.NotNil(t, view)
+	assert.True(t, view.View)
+	assert.Len(t, view.Columns, 2)
+}
@@ -0,0 +1,58 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/mpuzanov/dbwrap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTx(t *testing.T) {
+	cfg := dbwrap.NewConfig("sqlite3").WithDB(filepath.Join(t.TempDir(), "app.db"))
+	cfg.SQLite.JournalMode = "WAL"
+	db, err := dbwrap.NewConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+	createItems(t, db, 3)
+
+	tx, err := db.BeginTx(ctxDefault, nil)
+	require.NoError(t, err)
+	_, err = tx.NamedExecContext(ctxDefault, "UPDATE items SET name = :name WHERE id = :id", Item{ID: 1, Name: "changed"})
+	require.NoError(t, err)
+	var name string
+	require.NoError(t, tx.GetContext(ctxDefault, &name, "SELECT name FROM items WHERE id = ?", 1))
+	assert.Equal(t, "changed", name)
+	// вне транзакции изменения не видны
+	require.NoError(t, db.GetContext(ctxDefault, &name, "SELECT name FROM items WHERE id = ?", 1))
+	assert.Equal(t, "item 01", name)
+	_, err = tx.BeginTx(ctxDefault, nil)
+	assert.ErrorIs(t, err, dbwrap.ErrNestedTx)
+	require.NoError(t, tx.Rollback())
+	require.NoError(t, tx.Close())
+
+	errStop := errors.New("stop")
+	err = db.WithTx(ctxDefault, nil, func(tx *dbwrap.Tx) error {
+		_, err := tx.ExecContext(ctxDefault, "DELETE FROM items WHERE id IN (?)", []int{1, 2})
+		require.NoError(t, err)
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	var count int
+	require.NoError(t, db.GetContext(ctxDefault, &count, "SELECT COUNT(*) FROM items"))
+	assert.Equal(t, 3, count)
+
+	err = db.WithTx(ctxDefault, nil, func(tx *dbwrap.Tx) error {
+		rows, err := tx.SelectMapsContext(context.Background(), "SELECT * FROM items")
+		require.NoError(t, err)
+		assert.Len(t, rows, 3)
+		_, err = tx.ExecContext(ctxDefault, "DELETE FROM items WHERE id = ?", 3)
+		return err
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.GetContext(ctxDefault, &count, "SELECT COUNT(*) FROM items"))
+	assert.Equal(t, 2, count)
+}
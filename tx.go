@@ -0,0 +1,97 @@
+package dbwrap
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/multierr"
+)
+
+// ErrNestedTx ошибка.
+var ErrNestedTx = errors.New("транзакция уже начата, вложенные транзакции не поддерживаются")
+
+// Tx транзакция на основной БД. Методы DBSQL (ExecContext, SelectContext, GetContext ...)
+// выполняются в транзакции.
+type Tx struct {
+	*DBSQL
+}
+
+// BeginTx начало транзакции на основной БД.
+//
+// tx, err := db.BeginTx(ctx, nil)
+//
+// defer tx.Rollback()
+//
+// _, err = tx.ExecContext(ctx, "update accounts set balance = balance - ? where id = ?", sum, id)
+//
+// err = tx.Commit()
+func (d *DBSQL) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	if d.tx != nil {
+		return nil, ErrNestedTx
+	}
+	tx, err := d.DBX.BeginTxx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{DBSQL: &DBSQL{
+		DBX:          d.DBX,
+		timeoutQuery: d.timeoutQuery,
+		dialect:      d.dialect,
+		inOptions:    d.inOptions,
+		logger:       d.logger,
+		tx:           tx,
+	}}, nil
+}
+
+// WithTx выполнение fn в транзакции: Commit, если fn вернула nil, иначе Rollback.
+// При панике в fn транзакция отменяется и паника продолжается.
+func (d *DBSQL) WithTx(ctx context.Context, opts *sql.TxOptions, fn func(tx *Tx) error) (err error) {
+	tx, err := d.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+	if err = fn(tx); err != nil {
+		return multierr.Append(err, tx.Rollback())
+	}
+	return tx.Commit()
+}
+
+// Commit подтверждение транзакции.
+func (t *Tx) Commit() error {
+	return t.tx.Commit()
+}
+
+// Rollback отмена транзакции, после Commit возвращает sql.ErrTxDone.
+func (t *Tx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// Close отмена незавершённой транзакции, соединения DBSQL не закрываются.
+func (t *Tx) Close() error {
+	if err := t.tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return fmt.Errorf("tx close: %w", err)
+	}
+	return nil
+}
+
+// Tx транзакция sqlx для запросов в обход методов DBSQL.
+func (t *Tx) Tx() *sqlx.Tx {
+	return t.tx
+}
+
+// ext соединение для запросов: транзакция или основная БД.
+func (d *DBSQL) ext() sqlx.ExtContext {
+	if d.tx != nil {
+		return d.tx
+	}
+	return d.DBX
+}
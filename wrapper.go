@@ -22,17 +22,34 @@ func namedQuery(query string, arg any) (nq string, args []any, err error) {
 	return nq, args, nil
 }
 
+// bindNamed подстановка именованных параметров, раскрытие списков IN и приведение к параметрам драйвера.
+func (d *DBSQL) bindNamed(query string, arg any) (string, []any, error) {
+	nq, args, err := namedQuery(query, arg)
+	if err != nil {
+		return "", nil, err
+	}
+	nq, args, err = d.expandIn(sqlx.QUESTION, nq, args)
+	if err != nil {
+		return "", nil, sqlErr(err, query, args...)
+	}
+	return d.DBX.Rebind(nq), args, nil
+}
+
 // ExecContext Выполнение запроса DML.
 func (d *DBSQL) ExecContext(ctx context.Context, query string, args ...any) (int64, error) {
+	query, args, err := d.expandIn(d.Dialect().BindType(), query, args)
+	if err != nil {
+		return 0, sqlErr(err, query, args...)
+	}
 
-	// ограничим время выполнения запроса по умолчанию
-	dur := time.Duration(d.timeoutQuery) * time.Second
-	ctx, cancel := context.WithTimeout(ctx, dur)
+	// ограничим время выполнения запроса по умолчанию или QueryTimeout
+	ctx, cancel := d.queryContext(ctx)
 	defer cancel()
+	start := time.Now()
 
-	result, err := d.DBX.ExecContext(ctx, query, args...)
-	if err != nil {
-		return 0, sqlErr(err, query, args...)
+	result, err := d.ext().ExecContext(ctx, query, args...)
+	if err = d.done(ctx, start, err, query, args...); err != nil {
+		return 0, err
 	}
 	count, err := result.RowsAffected()
 	if err != nil {
@@ -44,12 +61,12 @@ func (d *DBSQL) ExecContext(ctx context.Context, query string, args ...any) (int
 // NamedExecContext Выполнение запроса DML.
 func (d *DBSQL) NamedExecContext(ctx context.Context, query string, arg any) (int64, error) {
 
-	nq, args, err := namedQuery(query, arg)
+	nq, args, err := d.bindNamed(query, arg)
 	if err != nil {
 		return 0, err
 	}
 
-	return d.ExecContext(ctx, d.DBX.Rebind(nq), args...)
+	return d.ExecContext(ctx, nq, args...)
 }
 
 // SelectContext получаем данные из запроса в слайс структур.
@@ -58,14 +75,26 @@ func (d *DBSQL) NamedExecContext(ctx context.Context, query string, arg any) (in
 //
 // err := ts.db.Select(ctx, &users, "select * from users")
 func (d *DBSQL) SelectContext(ctx context.Context, dest any, query string, args ...any) error {
+	query, args, err := d.expandIn(d.Dialect().BindType(), query, args)
+	if err != nil {
+		return sqlErr(err, query, args...)
+	}
 
-	// ограничим время выполнения запроса по умолчанию
-	dur := time.Duration(d.timeoutQuery) * time.Second
-	ctx, cancel := context.WithTimeout(ctx, dur)
+	// ограничим время выполнения запроса по умолчанию или QueryTimeout
+	ctx, cancel := d.queryContext(ctx)
 	defer cancel()
+	start := time.Now()
 
-	if err := sqlx.SelectContext(ctx, d.DBX, dest, query, args...); err != nil {
-		return sqlErr(err, query, args...)
+	err = retryRead(ctx, dest, func() error {
+		return d.read(ctx, func(q sqlx.QueryerContext) error {
+			if maxRows := maxRowsFrom(ctx); maxRows > 0 {
+				return selectMax(ctx, q, dest, maxRows, query, args...)
+			}
+			return sqlx.SelectContext(ctx, q, dest, query, args...)
+		})
+	})
+	if err = d.done(ctx, start, err, query, args...); err != nil {
+		return err
 	}
 
 	return nil
@@ -78,24 +107,44 @@ func (d *DBSQL) SelectContext(ctx context.Context, dest any, query string, args
 // err := ts.db.NamedSelectContext(ctx, &users, "select * from users where name=:Name", map[string]any{"Name": "admin"})
 func (d *DBSQL) NamedSelectContext(ctx context.Context, dest any, query string, arg any) error {
 
-	nq, args, err := namedQuery(query, arg)
+	nq, args, err := d.bindNamed(query, arg)
 	if err != nil {
 		return err
 	}
 
-	return d.SelectContext(ctx, dest, d.DBX.Rebind(nq), args...)
+	return d.SelectContext(ctx, dest, nq, args...)
 }
 
 // SelectMapsContext ...
 func (d *DBSQL) SelectMapsContext(ctx context.Context, query string, args ...any) (ret []map[string]any, err error) {
+	query, args, err = d.expandIn(d.Dialect().BindType(), query, args)
+	if err != nil {
+		return nil, sqlErr(err, query, args...)
+	}
 
-	dur := time.Duration(d.timeoutQuery) * time.Second
-	ctx, cancel := context.WithTimeout(ctx, dur)
+	// ограничим время выполнения запроса по умолчанию или QueryTimeout
+	ctx, cancel := d.queryContext(ctx)
 	defer cancel()
+	start := time.Now()
+
+	err = retryRead(ctx, nil, func() (err error) {
+		return d.read(ctx, func(q sqlx.QueryerContext) (err error) {
+			ret, err = selectMaps(ctx, q, maxRowsFrom(ctx), query, args...)
+			return err
+		})
+	})
+	if err = d.done(ctx, start, err, query, args...); err != nil {
+		return nil, err
+	}
 
-	rows, err := d.DBX.QueryxContext(ctx, query, args...)
+	return ret, nil
+}
+
+// selectMaps чтение строк запроса в слайс map, при maxRows > 0 не более maxRows строк.
+func selectMaps(ctx context.Context, q sqlx.QueryerContext, maxRows int, query string, args ...any) (ret []map[string]any, err error) {
+	rows, err := q.QueryxContext(ctx, query, args...)
 	if err != nil {
-		return nil, sqlErr(err, query, args...)
+		return nil, err
 	}
 
 	defer func() {
@@ -105,6 +154,9 @@ func (d *DBSQL) SelectMapsContext(ctx context.Context, query string, args ...any
 	ret = []map[string]any{}
 	numCols := -1
 	for rows.Next() {
+		if maxRows > 0 && len(ret) >= maxRows {
+			return nil, fmt.Errorf("%w: %d", ErrMaxRows, maxRows)
+		}
 		var m map[string]any
 		if numCols < 0 {
 			m = map[string]any{}
@@ -113,57 +165,64 @@ func (d *DBSQL) SelectMapsContext(ctx context.Context, query string, args ...any
 		}
 
 		if err = rows.MapScan(m); err != nil {
-			return nil, sqlErr(err, query, args...)
-		}
-
-		for key, val := range m {
-			switch v := val.(type) {
-			case []byte:
-				if resFloat, err := strconv.ParseFloat(string(v), 64); err == nil {
-					m[key] = resFloat
-					continue
-				}
-				if v, ok := val.([]uint8); ok {
-					m[key] = string(v)
-				} else {
-					m[key] = v
-				}
-			default:
-				m[key] = v
-			}
+			return nil, err
 		}
+		convertBytes(m)
 
 		ret = append(ret, m)
 		numCols = len(m)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, sqlErr(err, query, args...)
+		return nil, err
 	}
 
 	return ret, nil
 }
 
+// convertBytes значения []byte из драйвера преобразуются в число или строку.
+func convertBytes(m map[string]any) {
+	for key, val := range m {
+		switch v := val.(type) {
+		case []byte:
+			if resFloat, err := strconv.ParseFloat(string(v), 64); err == nil {
+				m[key] = resFloat
+				continue
+			}
+			m[key] = string(v)
+		}
+	}
+}
+
 // NamedSelectMapsContext ...
 func (d *DBSQL) NamedSelectMapsContext(ctx context.Context, query string, arg any) (ret []map[string]any, err error) {
-	nq, args, err := namedQuery(query, arg)
+	nq, args, err := d.bindNamed(query, arg)
 	if err != nil {
 		return nil, err
 	}
 
-	return d.SelectMapsContext(ctx, d.DBX.Rebind(nq), args...)
+	return d.SelectMapsContext(ctx, nq, args...)
 }
 
 // GetContext ...
 func (d *DBSQL) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	query, args, err := d.expandIn(d.Dialect().BindType(), query, args)
+	if err != nil {
+		return sqlErr(err, query, args...)
+	}
 
-	// ограничим время выполнения запроса по умолчанию
-	dur := time.Duration(d.timeoutQuery) * time.Second
-	ctx, cancel := context.WithTimeout(ctx, dur)
+	// ограничим время выполнения запроса по умолчанию или QueryTimeout
+	ctx, cancel := d.queryContext(ctx)
 	defer cancel()
-
-	if err := sqlx.GetContext(ctx, d.DBX, dest, query, args...); err != nil {
-		return sqlErr(err, query, args...)
+	start := time.Now()
+
+	err = retryRead(ctx, dest, func() error {
+		return d.read(ctx, func(q sqlx.QueryerContext) error {
+			return sqlx.GetContext(ctx, q, dest, query, args...)
+		})
+	})
+	if err = d.done(ctx, start, err, query, args...); err != nil {
+		return err
 	}
 
 	return nil
@@ -171,57 +230,60 @@ func (d *DBSQL) GetContext(ctx context.Context, dest any, query string, args ...
 
 // NamedGetContext ...
 func (d *DBSQL) NamedGetContext(ctx context.Context, dest any, query string, arg any) error {
-	nq, args, err := namedQuery(query, arg)
+	nq, args, err := d.bindNamed(query, arg)
 	if err != nil {
 		return err
 	}
 
-	return d.GetContext(ctx, dest, d.DBX.Rebind(nq), args...)
+	return d.GetContext(ctx, dest, nq, args...)
 }
 
 // GetMapContext ...
 func (d *DBSQL) GetMapContext(ctx context.Context, query string, args ...any) (ret map[string]any, err error) {
-	// ограничим время выполнения запроса по умолчанию
-	dur := time.Duration(d.timeoutQuery) * time.Second
-	ctx, cancel := context.WithTimeout(ctx, dur)
+	query, args, err = d.expandIn(d.Dialect().BindType(), query, args)
+	if err != nil {
+		return nil, sqlErr(err, query, args...)
+	}
+	// ограничим время выполнения запроса по умолчанию или QueryTimeout
+	ctx, cancel := d.queryContext(ctx)
 	defer cancel()
+	start := time.Now()
+
+	err = retryRead(ctx, nil, func() (err error) {
+		return d.read(ctx, func(q sqlx.QueryerContext) (err error) {
+			ret, err = getMap(ctx, q, query, args...)
+			return err
+		})
+	})
+	if err = d.done(ctx, start, err, query, args...); err != nil {
+		return nil, err
+	}
 
-	row := d.DBX.QueryRowxContext(ctx, query, args...)
+	return ret, nil
+}
+
+// getMap чтение первой строки запроса в map.
+func getMap(ctx context.Context, q sqlx.QueryerContext, query string, args ...any) (map[string]any, error) {
+	row := q.QueryRowxContext(ctx, query, args...)
 	if row.Err() != nil {
-		return nil, sqlErr(row.Err(), query, args...)
+		return nil, row.Err()
 	}
 
-	ret = map[string]any{}
+	ret := map[string]any{}
 	if err := row.MapScan(ret); err != nil {
-		return nil, sqlErr(err, query, args...)
-	}
-
-	for key, val := range ret {
-		switch v := val.(type) {
-		case []byte:
-			if resFloat, err := strconv.ParseFloat(string(v), 64); err == nil {
-				ret[key] = resFloat
-				continue
-			}
-			if v, ok := val.([]uint8); ok {
-				ret[key] = string(v)
-			} else {
-				ret[key] = v
-			}
-		default:
-			ret[key] = v
-		}
+		return nil, err
 	}
+	convertBytes(ret)
 
 	return ret, nil
 }
 
 // NamedGetMapContext ...
 func (d *DBSQL) NamedGetMapContext(ctx context.Context, query string, arg any) (ret map[string]any, err error) {
-	nq, args, err := namedQuery(query, arg)
+	nq, args, err := d.bindNamed(query, arg)
 	if err != nil {
 		return nil, err
 	}
 
-	return d.GetMapContext(ctx, d.DBX.Rebind(nq), args...)
+	return d.GetMapContext(ctx, nq, args...)
 }